@@ -0,0 +1,87 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+)
+
+const apiResourcesDesc = `
+	Usage:
+		vib api-resources [flags]
+	Description:
+		List the registered kinds with their names, short names, preferred
+		apiVersion and whether they are namespaced. Kinds can be referred to
+		by any of their names, e.g. "vib get es".`
+
+// NewAPIResources creates a new "api-resources" command.
+func NewAPIResources(apiServer types.APIServer) Command {
+	return &apiResources{
+		apiServer: apiServer,
+		fs:        flag.NewFlagSet("api-resources", flag.ExitOnError),
+	}
+}
+
+// apiResources holds the dependencies and flags for the "api-resources" command.
+type apiResources struct {
+	apiServer types.APIServer
+	fs        *flag.FlagSet
+}
+
+// Description implements the Command interface.
+func (a *apiResources) Description() string {
+	return apiResourcesDesc
+}
+
+// FS implements the Command interface.
+func (a *apiResources) FS() *flag.FlagSet {
+	return a.fs
+}
+
+// Run implements the Command interface.
+func (a *apiResources) Run() error {
+	if a.fs.NArg() != 0 {
+		return flaterrors.Join(
+			errors.New("\"API-RESOURCES\" expects NO argument"),
+			errUsage,
+		)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSHORTNAMES\tAPIVERSION\tNAMESPACED\tKIND") //nolint: errcheck
+
+	for _, res := range a.apiServer.APIResources() {
+		fmt.Fprintf( //nolint: errcheck
+			w,
+			"%s\t%s\t%s\t%t\t%s\n",
+			res.Names.Plural,
+			strings.Join(res.Names.ShortNames, ","),
+			res.APIVersion,
+			res.Namespaced,
+			res.Kind,
+		)
+	}
+
+	return w.Flush()
+}
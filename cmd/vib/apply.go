@@ -22,19 +22,23 @@ import (
 	"log/slog"
 	"os"
 
+	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
 	"github.com/alexandremahdhaoui/vib/internal/types"
 )
 
 // NewApply creates a new "apply" command.
 func NewApply(
+	apiServer types.APIServer,
 	decoder types.DynamicDecoder[types.APIVersionKind],
 	storage types.Storage,
 ) Command {
 	out := &apply{
+		apiServer: apiServer,
 		decoder:   decoder,
 		filePath:  "",
 		fs:        flag.NewFlagSet("apply", flag.ExitOnError),
 		namespace: "",
+		refPolicy: "",
 		storage:   storage,
 	}
 
@@ -46,6 +50,7 @@ func NewApply(
 	)
 
 	NewNamespaceFlag(out.fs, &out.namespace)
+	NewRefPolicyFlag(out.fs, &out.refPolicy)
 
 	return out
 }
@@ -54,14 +59,19 @@ const applyDesc = `
 	Usage:
 		vib apply [flags]
 	Description:
-		Create or edit the the provided resources.`
+		Create or edit the the provided resources.
+		Resources referencing resources that neither exist nor are part of the
+		provided resources are rejected, or accepted with a warning if
+		"-ref-policy=warn" is set.`
 
 // apply holds the dependencies and flags for the "apply" command.
 type apply struct {
+	apiServer types.APIServer
 	decoder   types.DynamicDecoder[types.APIVersionKind]
 	filePath  string
 	fs        *flag.FlagSet
 	namespace string
+	refPolicy string
 	storage   types.Storage
 }
 
@@ -79,7 +89,10 @@ func (a *apply) FS() *flag.FlagSet {
 func (a *apply) Run() error {
 	filePath := a.filePath
 	if filePath == "" {
-		return errors.New(`a valid file must be provided using the "-f" flag`)
+		return flaterrors.Join(
+			errors.New(`a valid file must be provided using the "-f" flag`),
+			errUsage,
+		)
 	}
 
 	if filePath == "-" {
@@ -96,16 +109,23 @@ func (a *apply) Run() error {
 		return err
 	}
 
-	for _, res := range list {
+	for i := range list {
 		// -- set namespace if namespace is not specified or flag is set.
-		if res.Metadata.Namespace == "" || a.namespace != "default" {
-			res.Metadata.Namespace = a.namespace
+		if list[i].Metadata.Namespace == "" || a.namespace != "default" {
+			list[i].Metadata.Namespace = a.namespace
 		}
 
-		if err := types.ValidateResource(res); err != nil {
+		if err := a.apiServer.Validate(list[i]); err != nil {
 			return err
 		}
+	}
 
+	// -- resources may reference each other.
+	if err := ValidateReferences(a.storage, list, a.refPolicy); err != nil {
+		return err
+	}
+
+	for _, res := range list {
 		verb := "created"
 		err := a.storage.Create(res)
 		if errors.Is(err, types.ErrExists) {
@@ -0,0 +1,395 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+)
+
+// Exit codes of vib.
+const (
+	// exitOK is returned when the command succeeds, or when help is requested.
+	exitOK = 0
+	// exitError is returned when the command fails.
+	exitError = 1
+	// exitUsage is returned when the command line is invalid, e.g. an unknown command or flag, or
+	// missing arguments.
+	exitUsage = 2
+)
+
+// errUsage is returned by commands invoked with invalid arguments. The help of the command is then
+// printed, and vib exits with exitUsage.
+var errUsage = errors.New("invalid usage")
+
+// CommandGroup is implemented by commands holding subcommands, e.g. "completion bash".
+type CommandGroup interface {
+	Command
+	// Subcommands returns the subcommands of the command.
+	Subcommands() []Command
+}
+
+// globalFlags holds the flags that apply to all commands.
+type globalFlags struct {
+	configDir       string
+	storageEncoding string
+	verbose         bool
+}
+
+// newGlobalFlagSet defines the global flags.
+func newGlobalFlagSet(g *globalFlags) *flag.FlagSet {
+	fs := flag.NewFlagSet("vib", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	fs.StringVar(
+		&g.configDir,
+		"config-dir",
+		"",
+		`The directory of the config and of the resources. Defaults to "CONFIG_DIR/vib", e.g. "~/.config/vib"`,
+	)
+
+	fs.StringVar(
+		&g.storageEncoding,
+		"storage-encoding",
+		"",
+		`Overrides the "storageEncoding" of the config; must be one of [json,jsonc,toml,yaml]`,
+	)
+
+	fs.BoolVar(&g.verbose, "v", false, "Enable debug logs")
+
+	return fs
+}
+
+// parseGlobalFlags sets the global flags found anywhere in args, and returns the other arguments.
+// Arguments following "--" are left unchanged.
+func parseGlobalFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	out := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(out, args[i:]...), nil
+		}
+
+		fl, value, hasValue := lookupFlag(fs, arg)
+		if fl == nil {
+			out = append(out, arg)
+			continue
+		}
+
+		switch {
+		case hasValue:
+		case isBoolFlag(fl):
+			value = "true"
+		case i+1 < len(args):
+			i++
+			value = args[i]
+		default:
+			return nil, fmt.Errorf("flag needs an argument: -%s", fl.Name)
+		}
+
+		if err := fs.Set(fl.Name, value); err != nil {
+			return nil, fmt.Errorf("invalid value %q for flag -%s: %w", value, fl.Name, err)
+		}
+	}
+
+	return out, nil
+}
+
+// lookupFlag returns the flag of fs named by arg, e.g. "-n", "--n" or "-n=default", and its value
+// if set in arg. It returns nil if arg is not a flag of fs.
+func lookupFlag(fs *flag.FlagSet, arg string) (*flag.Flag, string, bool) {
+	if !strings.HasPrefix(arg, "-") || arg == "-" || arg == "--" {
+		return nil, "", false
+	}
+
+	name, value, hasValue := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-"), "=")
+
+	return fs.Lookup(name), value, hasValue
+}
+
+// isBoolFlag returns true if the flag does not expect a value, e.g. "-force".
+func isBoolFlag(fl *flag.Flag) bool {
+	b, ok := fl.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// parseInterspersed parses the flags of fs found anywhere in args, e.g. "get profile foo -o json".
+// Arguments following "--" are positional. The positional arguments are then available through
+// fs.Args.
+func parseInterspersed(fs *flag.FlagSet, args []string) error {
+	positionals := make([]string, 0)
+	for {
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+
+		rest := fs.Args()
+
+		// -- Parse consumes the "--" terminating the flags.
+		if n := len(args) - len(rest); n > 0 && args[n-1] == "--" {
+			positionals = append(positionals, rest...)
+			break
+		}
+
+		if len(rest) == 0 {
+			break
+		}
+
+		positionals = append(positionals, rest[0])
+		args = rest[1:]
+	}
+
+	return fs.Parse(append([]string{"--"}, positionals...))
+}
+
+// cli dispatches the command line to the commands.
+type cli struct {
+	cmds   []Command
+	global *flag.FlagSet
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// newCLI returns a new cli running cmds. The global flags are only used to print help.
+func newCLI(cmds []Command, global *flag.FlagSet) *cli {
+	return &cli{cmds: cmds, global: global, stdout: os.Stdout, stderr: os.Stderr}
+}
+
+// run runs the command named by args, without the global flags, and returns the exit code.
+// "vib help [COMMAND...]" and "vib COMMAND -h" print the help of vib or of a command.
+func (c *cli) run(args []string) int {
+	if len(args) == 0 {
+		c.printHelp(c.stderr)
+		return exitUsage
+	}
+
+	if slices.Contains([]string{"help", "-h", "-help", "--help"}, args[0]) {
+		return c.help(args[1:])
+	}
+
+	path, cmd, rest := c.resolve(args)
+	if cmd == nil {
+		slog.Error(fmt.Sprintf("unknown command %q", args[0]))
+		c.printHelp(c.stderr)
+
+		return exitUsage
+	}
+
+	fs := cmd.FS()
+	fs.Init(fs.Name(), flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Usage = func() {}
+
+	if err := parseInterspersed(fs, rest); errors.Is(err, flag.ErrHelp) {
+		c.printCommandHelp(c.stdout, path, cmd)
+		return exitOK
+	} else if err != nil {
+		slog.Error(err.Error())
+		c.printCommandHelp(c.stderr, path, cmd)
+
+		return exitUsage
+	}
+
+	if err := cmd.Run(); errors.Is(err, errUsage) {
+		slog.Error(err.Error())
+		c.printCommandHelp(c.stderr, path, cmd)
+
+		return exitUsage
+	} else if err != nil {
+		slog.Error(err.Error())
+		return exitError
+	}
+
+	return exitOK
+}
+
+// help prints the help of the command named by args, or of vib if args is empty.
+func (c *cli) help(args []string) int {
+	if len(args) == 0 {
+		c.printHelp(c.stdout)
+		return exitOK
+	}
+
+	path, cmd, rest := c.resolve(args)
+	if cmd == nil || len(rest) > 0 {
+		slog.Error(fmt.Sprintf("unknown command %q", strings.Join(args, " ")))
+		c.printHelp(c.stderr)
+
+		return exitUsage
+	}
+
+	c.printCommandHelp(c.stdout, path, cmd)
+
+	return exitOK
+}
+
+// resolve returns the path and the command named by the first arguments, descending into the
+// subcommands of CommandGroups, and the remaining arguments. The command is nil if it does not
+// exist.
+func (c *cli) resolve(args []string) ([]string, Command, []string) {
+	cmd := findCommand(c.cmds, args[0])
+	if cmd == nil {
+		return nil, nil, nil
+	}
+
+	path, rest := []string{args[0]}, args[1:]
+	for {
+		group, ok := cmd.(CommandGroup)
+		if !ok || len(rest) == 0 {
+			return path, cmd, rest
+		}
+
+		sub := findCommand(group.Subcommands(), rest[0])
+		if sub == nil {
+			return path, cmd, rest
+		}
+
+		cmd, path, rest = sub, append(path, rest[0]), rest[1:]
+	}
+}
+
+// findCommand returns the command of cmds named name, or nil.
+func findCommand(cmds []Command, name string) Command {
+	for _, cmd := range cmds {
+		if cmd.FS().Name() == name {
+			return cmd
+		}
+	}
+
+	return nil
+}
+
+const usageFmt = `Usage:
+	vib [global flags] COMMAND [flags] [args]
+
+Commands:
+`
+
+// printHelp prints the usage of vib: the commands, with a summary of their description, and the
+// global flags.
+func (c *cli) printHelp(w io.Writer) {
+	fmt.Fprint(w, usageFmt) //nolint: errcheck
+	writeCommandSummaries(w, c.cmds)
+
+	fmt.Fprintln(w, "\nGlobal Flags:") //nolint: errcheck
+	writeFlags(w, c.global)
+
+	fmt.Fprintln(w, "\nRun \"vib help COMMAND\" or \"vib COMMAND -h\" for more information about a command.") //nolint: errcheck
+}
+
+// printCommandHelp prints the description, the subcommands and the flags of a command.
+func (c *cli) printCommandHelp(w io.Writer, path []string, cmd Command) {
+	fmt.Fprintf(w, "vib %s\n", strings.Join(path, " "))         //nolint: errcheck
+	fmt.Fprintln(w, strings.TrimRight(cmd.Description(), "\n")) //nolint: errcheck
+
+	if group, ok := cmd.(CommandGroup); ok {
+		fmt.Fprintln(w, "\nCommands:") //nolint: errcheck
+		writeCommandSummaries(w, group.Subcommands())
+	}
+
+	hasFlags := false
+	cmd.FS().VisitAll(func(*flag.Flag) { hasFlags = true })
+
+	if hasFlags {
+		fmt.Fprintln(w, "\nFlags:") //nolint: errcheck
+		writeFlags(w, cmd.FS())
+	}
+
+	fmt.Fprintln(w, "\nGlobal Flags:") //nolint: errcheck
+	writeFlags(w, c.global)
+}
+
+// writeCommandSummaries writes the names of the visible commands with the first sentence of their
+// description.
+func writeCommandSummaries(w io.Writer, cmds []Command) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, cmd := range cmds {
+		if strings.HasPrefix(cmd.FS().Name(), hiddenCommandPrefix) {
+			continue
+		}
+
+		fmt.Fprintf(tw, "\t%s\t%s\n", cmd.FS().Name(), commandSummary(cmd.Description())) //nolint: errcheck
+	}
+
+	_ = tw.Flush()
+}
+
+// writeFlags writes the flags of fs with their usage and their default value, unless the usage
+// mentions it. Flags with long names are written with two dashes, e.g. "--apiVersion".
+func writeFlags(w io.Writer, fs *flag.FlagSet) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fs.VisitAll(func(fl *flag.Flag) {
+		name := "-" + fl.Name
+		if len(fl.Name) > 1 {
+			name = "-" + name
+		}
+
+		if !isBoolFlag(fl) {
+			name += " value"
+		}
+
+		usage := fl.Usage
+		if fl.DefValue != "" && !isBoolFlag(fl) && !strings.Contains(usage, "default") {
+			usage += fmt.Sprintf(" (default %q)", fl.DefValue)
+		}
+
+		fmt.Fprintf(tw, "\t%s\t%s\n", name, usage) //nolint: errcheck
+	})
+
+	_ = tw.Flush()
+}
+
+// commandSummary returns the first sentence of the "Description:" section of the description of a
+// command.
+func commandSummary(description string) string {
+	lines := strings.Split(description, "\n")
+	start := slices.IndexFunc(lines, func(line string) bool {
+		return strings.TrimSpace(line) == "Description:"
+	})
+
+	if start < 0 {
+		return ""
+	}
+
+	words := make([]string, 0)
+	for _, line := range lines[start+1:] {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasSuffix(line, ":") && !strings.Contains(line, " ") {
+			break
+		}
+
+		words = append(words, strings.Fields(line)...)
+	}
+
+	// -- sentences end with a period followed by a capitalized word, unlike "e.g." or "i.e.".
+	for i := 0; i+1 < len(words); i++ {
+		if strings.HasSuffix(words[i], ".") &&
+			!slices.Contains([]string{"e.g.", "i.e."}, words[i]) &&
+			unicode.IsUpper([]rune(words[i+1])[0]) {
+			return strings.Join(words[:i+1], " ")
+		}
+	}
+
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInterspersed(t *testing.T) {
+	for _, tc := range []struct {
+		Name     string
+		Args     []string
+		WantArgs []string
+		WantO    string
+		WantErr  bool
+	}{
+		{Name: "FlagsFirst", Args: []string{"-o", "json", "es", "git"}, WantArgs: []string{"es", "git"}, WantO: "json"},
+		{Name: "FlagsLast", Args: []string{"es", "git", "-o", "json"}, WantArgs: []string{"es", "git"}, WantO: "json"},
+		{Name: "FlagsBetween", Args: []string{"es", "-o=json", "git"}, WantArgs: []string{"es", "git"}, WantO: "json"},
+		{Name: "DoubleDash", Args: []string{"--o", "json", "es"}, WantArgs: []string{"es"}, WantO: "json"},
+		{
+			Name:     "Terminator",
+			Args:     []string{"es", "--", "-o", "json"},
+			WantArgs: []string{"es", "-o", "json"},
+			WantO:    "yaml",
+		},
+		{Name: "NoArgs", Args: []string{}, WantArgs: []string{}, WantO: "yaml"},
+		{Name: "UnknownFlag", Args: []string{"es", "-x"}, WantErr: true},
+		{Name: "MissingValue", Args: []string{"es", "-o"}, WantErr: true},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			fs := flag.NewFlagSet("get", flag.ContinueOnError)
+			fs.Usage = func() {}
+			o := fs.String("o", "yaml", "")
+
+			err := parseInterspersed(fs, tc.Args)
+			if tc.WantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.WantArgs, fs.Args())
+			assert.Equal(t, tc.WantO, *o)
+		})
+	}
+}
+
+func TestParseGlobalFlags(t *testing.T) {
+	for _, tc := range []struct {
+		Name     string
+		Args     []string
+		WantArgs []string
+		Want     globalFlags
+		WantErr  bool
+	}{
+		{
+			Name:     "BeforeCommand",
+			Args:     []string{"--config-dir", "/tmp/vib", "get", "es"},
+			WantArgs: []string{"get", "es"},
+			Want:     globalFlags{configDir: "/tmp/vib"},
+		},
+		{
+			Name:     "AfterCommand",
+			Args:     []string{"get", "-v", "es", "-storage-encoding=json", "-o", "json"},
+			WantArgs: []string{"get", "es", "-o", "json"},
+			Want:     globalFlags{storageEncoding: "json", verbose: true},
+		},
+		{
+			Name:     "Terminator",
+			Args:     []string{"__complete", "--", "--config-dir", "/tmp/vib", "get"},
+			WantArgs: []string{"__complete", "--", "--config-dir", "/tmp/vib", "get"},
+		},
+		{Name: "MissingValue", Args: []string{"get", "--config-dir"}, WantErr: true},
+		{Name: "InvalidValue", Args: []string{"-v=maybe", "get"}, WantErr: true},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			got := globalFlags{}
+
+			args, err := parseGlobalFlags(newGlobalFlagSet(&got), tc.Args)
+			if tc.WantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.WantArgs, args)
+			assert.Equal(t, tc.Want, got)
+		})
+	}
+}
+
+func TestSetCompletedGlobalFlags(t *testing.T) {
+	got := globalFlags{configDir: "/etc/vib"}
+	setCompletedGlobalFlags(&got, []string{"__complete", "--", "get", "--config-dir", "/tmp/vib", "es", ""})
+	assert.Equal(t, globalFlags{configDir: "/tmp/vib"}, got)
+
+	// -- the word being completed is ignored.
+	got = globalFlags{}
+	setCompletedGlobalFlags(&got, []string{"__complete", "--", "--config-dir=/tmp/v"})
+	assert.Equal(t, globalFlags{}, got)
+}
+
+func TestCommandSummary(t *testing.T) {
+	for _, tc := range []struct {
+		Name        string
+		Description string
+		Want        string
+	}{
+		{
+			Name: "FirstSentence",
+			Description: `
+	Usage:
+		vib get [flags] KIND [NAME0] [NAME1]
+	Description:
+		Get resources of kind "KIND". Resources can be optionally filtered by
+		name.
+	Args:
+		KIND: the kind of the resource.`,
+			Want: `Get resources of kind "KIND".`,
+		},
+		{
+			Name: "Abbreviation",
+			Description: `
+	Description:
+		Print the dependency graph of resources, i.e. Profiles referencing
+		ExpressionSets. Namespaces are rendered as clusters.`,
+			Want: "Print the dependency graph of resources, i.e. Profiles referencing ExpressionSets.",
+		},
+		{
+			Name: "SingleSentence",
+			Description: `
+	Description:
+		Lists the registered kinds`,
+			Want: "Lists the registered kinds",
+		},
+		{Name: "NoDescription", Description: "\n\tUsage:\n\t\tvib __complete", Want: ""},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.Want, commandSummary(tc.Description))
+		})
+	}
+}
@@ -0,0 +1,485 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+)
+
+const completionDesc = `
+	Usage:
+		vib completion SHELL
+	Description:
+		Print the completion script of SHELL. The script completes commands,
+		flags, kinds, namespaces and resource names, e.g.:
+			bash: source <(vib completion bash)
+			zsh:  source <(vib completion zsh)
+			fish: vib completion fish | source
+	Args:
+		SHELL: one of [bash,zsh,fish].`
+
+// hiddenCommandPrefix prefixes the names of the commands that are not listed by "help".
+const hiddenCommandPrefix = "__"
+
+// completionScripts are the completion scripts of the supported shells. They call the hidden
+// "__complete" command with the words of the command line, following "--" so that they are not
+// parsed as its flags, and fall back to file completion if no candidate is returned.
+var completionScripts = map[string]string{
+	"bash": `_vib() {
+	local IFS=$'\n'
+	COMPREPLY=($(vib __complete -- "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+
+complete -o default -F _vib vib
+`,
+	"zsh": `#compdef vib
+
+_vib() {
+	local -a candidates
+	candidates=("${(@f)$(vib __complete -- "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+	if [[ -z "${candidates[1]}" ]]; then
+		_files
+		return
+	fi
+
+	compadd -a candidates
+}
+
+compdef _vib vib
+`,
+	"fish": `function __vib_complete
+	set -l candidates (vib __complete -- (commandline -opc)[2..-1] (commandline -ct) 2>/dev/null)
+	if test (count $candidates) -eq 0
+		__fish_complete_path (commandline -ct)
+		return
+	end
+
+	printf '%s\n' $candidates
+end
+
+complete -c vib -f -a '(__vib_complete)'
+`,
+}
+
+// NewCompletion creates a new "completion" command. Its subcommands print the completion script
+// of each supported shell.
+func NewCompletion() Command {
+	out := &completion{
+		fs:          flag.NewFlagSet("completion", flag.ExitOnError),
+		subcommands: make([]Command, 0, len(completionScripts)),
+	}
+
+	for _, shell := range slices.Sorted(maps.Keys(completionScripts)) {
+		out.subcommands = append(out.subcommands, &completionScript{
+			fs:     flag.NewFlagSet(shell, flag.ExitOnError),
+			script: completionScripts[shell],
+		})
+	}
+
+	return out
+}
+
+// completion holds the flags and the subcommands of the "completion" command.
+type completion struct {
+	fs          *flag.FlagSet
+	subcommands []Command
+}
+
+var _ CommandGroup = &completion{}
+
+// Description implements the Command interface.
+func (c *completion) Description() string {
+	return completionDesc
+}
+
+// FS implements the Command interface.
+func (c *completion) FS() *flag.FlagSet {
+	return c.fs
+}
+
+// Subcommands implements the CommandGroup interface.
+func (c *completion) Subcommands() []Command {
+	return c.subcommands
+}
+
+// Run implements the Command interface.
+// It is only called if SHELL is missing or unsupported.
+func (c *completion) Run() error {
+	if c.fs.NArg() == 0 {
+		return flaterrors.Join(errors.New("\"COMPLETION\" expects ONE argument"), errUsage)
+	}
+
+	return flaterrors.Join(fmt.Errorf("unsupported shell %q", c.fs.Arg(0)), errUsage)
+}
+
+const completionScriptDescFmt = `
+	Usage:
+		vib completion %[1]s
+	Description:
+		Print the %[1]s completion script.`
+
+// completionScript holds the flags and the script of the "completion SHELL" commands.
+type completionScript struct {
+	fs     *flag.FlagSet
+	script string
+}
+
+// Description implements the Command interface.
+func (c *completionScript) Description() string {
+	return fmt.Sprintf(completionScriptDescFmt, c.fs.Name())
+}
+
+// FS implements the Command interface.
+func (c *completionScript) FS() *flag.FlagSet {
+	return c.fs
+}
+
+// Run implements the Command interface.
+func (c *completionScript) Run() error {
+	if c.fs.NArg() != 0 {
+		return flaterrors.Join(
+			fmt.Errorf("\"COMPLETION %s\" expects NO argument", strings.ToUpper(c.fs.Name())),
+			errUsage,
+		)
+	}
+
+	fmt.Print(c.script)
+
+	return nil
+}
+
+// argType is the type of a positional argument, used to complete it.
+type argType int
+
+const (
+	// noArg is an argument that is not completed.
+	noArg argType = iota
+	// kindArg is the kind of a resource.
+	kindArg
+	// nameArg is the name of a resource of the kind given by the preceding kindArg.
+	nameArg
+)
+
+// completionArgs are the types of the positional arguments of the commands. The last type is
+// repeated, e.g. "get" completes the names of many resources. The subcommands of CommandGroups are
+// always completed.
+var completionArgs = map[string][]argType{
+	"create":   {kindArg, noArg},
+	"delete":   {kindArg, nameArg},
+	"describe": {kindArg, nameArg, noArg},
+	"edit":     {kindArg, nameArg},
+	"explain":  {kindArg, noArg},
+	"get":      {kindArg, nameArg},
+	"graph":    {kindArg, nameArg, noArg},
+	"history":  {kindArg, nameArg, noArg},
+	"render":   {kindArg, nameArg, noArg},
+	"restore":  {kindArg, nameArg, noArg},
+	"rollback": {kindArg, nameArg, noArg},
+	"schema":   {kindArg, noArg},
+}
+
+// NewComplete creates the hidden "__complete" command called by the completion scripts. It
+// completes the commands of cmds and the global flags.
+func NewComplete(apiServer types.APIServer, storage types.Storage, cmds []Command) Command {
+	return &complete{
+		apiServer: apiServer,
+		cmds:      cmds,
+		fs:        flag.NewFlagSet(hiddenCommandPrefix+"complete", flag.ExitOnError),
+		storage:   storage,
+	}
+}
+
+// setCompletedGlobalFlags sets the config dir and the storage encoding of g from the global flags of
+// the command line being completed, i.e. the words following "__complete --" but the last one, so
+// that the candidates are listed from the storage of that command line. A leading "~/" is expanded,
+// as shells do not expand the words they complete.
+func setCompletedGlobalFlags(g *globalFlags, args []string) {
+	if len(args) < 3 || args[0] != hiddenCommandPrefix+"complete" || args[1] != "--" {
+		return
+	}
+
+	completed := &globalFlags{}
+	if _, err := parseGlobalFlags(newGlobalFlagSet(completed), args[2:len(args)-1]); err != nil {
+		return
+	}
+
+	if completed.configDir != "" {
+		g.configDir = completed.configDir
+		if rest, ok := strings.CutPrefix(g.configDir, "~/"); ok {
+			if home, err := os.UserHomeDir(); err == nil {
+				g.configDir = filepath.Join(home, rest)
+			}
+		}
+	}
+
+	if completed.storageEncoding != "" {
+		g.storageEncoding = completed.storageEncoding
+	}
+}
+
+// complete holds the dependencies for the "__complete" command.
+type complete struct {
+	apiServer types.APIServer
+	cmds      []Command
+	fs        *flag.FlagSet
+	storage   types.Storage
+}
+
+// Description implements the Command interface.
+func (c *complete) Description() string {
+	return `
+	Usage:
+		vib __complete -- [WORD0] [WORD1] WORD
+	Description:
+		Print the candidates completing WORD, one per line. WORD{X} are the
+		preceding words of the command line, without "vib".`
+}
+
+// FS implements the Command interface.
+func (c *complete) FS() *flag.FlagSet {
+	return c.fs
+}
+
+// Run implements the Command interface.
+// Errors are not reported: they would be printed as candidates by the shell.
+func (c *complete) Run() error {
+	words := c.fs.Args()
+	if len(words) == 0 {
+		words = []string{""}
+	}
+
+	for _, candidate := range c.candidates(words) {
+		fmt.Println(candidate)
+	}
+
+	return nil
+}
+
+// candidates returns the candidates completing the last word.
+func (c *complete) candidates(words []string) []string {
+	current := words[len(words)-1]
+	global := newGlobalFlagSet(&globalFlags{})
+
+	// -- global flags may be set anywhere, e.g. before the command.
+	preceding, err := parseGlobalFlags(global, words[:len(words)-1])
+	if err != nil {
+		return nil
+	}
+
+	if len(preceding) == 0 {
+		if strings.HasPrefix(current, "-") {
+			return filterPrefix(flagNames(global, current), current)
+		}
+
+		return filterPrefix(commandNames(c.cmds), current)
+	}
+
+	cmd := findCommand(c.cmds, preceding[0])
+	if cmd == nil {
+		return nil
+	}
+
+	// -- descend into the subcommands.
+	i := 1
+	for group, ok := cmd.(CommandGroup); ok; group, ok = cmd.(CommandGroup) {
+		if i == len(preceding) {
+			return filterPrefix(commandNames(group.Subcommands()), current)
+		}
+
+		if cmd = findCommand(group.Subcommands(), preceding[i]); cmd == nil {
+			return nil
+		}
+
+		i++
+	}
+
+	fs := cmd.FS()
+
+	// -- scan the preceding words for positional arguments and flag values.
+	var (
+		positionals []string
+		valueOf     *flag.Flag
+	)
+
+	flagValues := map[string]string{"n": types.DefaultNamespace}
+	for _, word := range preceding[i:] {
+		if valueOf != nil {
+			flagValues[valueOf.Name] = word
+			valueOf = nil
+
+			continue
+		}
+
+		if !strings.HasPrefix(word, "-") || word == "-" {
+			positionals = append(positionals, word)
+			continue
+		}
+
+		fl, value, hasValue := lookupFlag(fs, word)
+		if fl == nil {
+			continue
+		}
+
+		if hasValue {
+			flagValues[fl.Name] = value
+		} else if !isBoolFlag(fl) {
+			valueOf = fl
+		}
+	}
+
+	// -- values of flags
+	if valueOf != nil {
+		switch valueOf.Name {
+		case "n":
+			return filterPrefix(c.namespaces(), current)
+		case "apiVersion":
+			return filterPrefix(c.apiVersions(), current)
+		default:
+			return nil
+		}
+	}
+
+	// -- flags
+	if strings.HasPrefix(current, "-") {
+		return filterPrefix(append(flagNames(fs, current), flagNames(global, current)...), current)
+	}
+
+	// -- positional arguments
+	argTypes := completionArgs[fs.Name()]
+	if len(argTypes) == 0 {
+		return nil
+	}
+
+	switch argTypes[min(len(positionals), len(argTypes)-1)] {
+	case kindArg:
+		return filterPrefix(c.kinds(), current)
+	case nameArg:
+		return filterPrefix(c.names(flagValues["apiVersion"], positionals[0], flagValues["n"]), current)
+	default:
+		return nil
+	}
+}
+
+// kinds returns the singular names of the registered kinds.
+func (c *complete) kinds() []string {
+	out := make([]string, 0)
+	for _, res := range c.apiServer.APIResources() {
+		if !slices.Contains(out, res.Names.Singular) {
+			out = append(out, res.Names.Singular)
+		}
+	}
+
+	return out
+}
+
+// apiVersions returns the registered APIVersions.
+func (c *complete) apiVersions() []string {
+	out := make([]string, 0)
+	for _, avk := range c.apiServer.List() {
+		for _, apiVersion := range c.apiServer.Versions(avk.Kind()) {
+			if !slices.Contains(out, apiVersion) {
+				out = append(out, apiVersion)
+			}
+		}
+	}
+
+	slices.Sort(out)
+
+	return out
+}
+
+// namespaces returns the namespaces of the storage.
+func (c *complete) namespaces() []string {
+	lister, ok := c.storage.(types.NamespaceLister)
+	if !ok {
+		return nil
+	}
+
+	out, err := lister.Namespaces()
+	if err != nil {
+		return nil
+	}
+
+	return out
+}
+
+// names returns the names of the resources of kind in namespace.
+func (c *complete) names(apiVersion types.APIVersion, kind, namespace string) []string {
+	res, err := c.apiServer.Get(types.NewAPIVersionKind(apiVersion, kind))
+	if err != nil {
+		return nil
+	}
+
+	list, err := c.storage.List(types.NewAVKFromResource(res), namespace)
+	if err != nil {
+		return nil
+	}
+
+	out := make([]string, 0, len(list))
+	for _, res := range list {
+		out = append(out, res.Metadata.Name)
+	}
+
+	return out
+}
+
+// commandNames returns the names of the visible commands.
+func commandNames(cmds []Command) []string {
+	out := make([]string, 0, len(cmds))
+	for _, cmd := range cmds {
+		if name := cmd.FS().Name(); !strings.HasPrefix(name, hiddenCommandPrefix) {
+			out = append(out, name)
+		}
+	}
+
+	return out
+}
+
+// flagNames returns the names of the flags of fs, prefixed by a dash, or by two dashes if the word
+// being completed starts with two dashes.
+func flagNames(fs *flag.FlagSet, current string) []string {
+	dashes := "-"
+	if strings.HasPrefix(current, "--") {
+		dashes = "--"
+	}
+
+	out := make([]string, 0)
+	fs.VisitAll(func(fl *flag.Flag) { out = append(out, dashes+fl.Name) })
+
+	return out
+}
+
+// filterPrefix returns the candidates starting with prefix.
+func filterPrefix(candidates []string, prefix string) []string {
+	out := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			out = append(out, candidate)
+		}
+	}
+
+	return out
+}
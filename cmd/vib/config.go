@@ -0,0 +1,151 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
+	admissionadapter "github.com/alexandremahdhaoui/vib/internal/adapter/admission"
+	codecadapter "github.com/alexandremahdhaoui/vib/internal/adapter/codec"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+	yaml "sigs.k8s.io/yaml/goyaml.v3"
+)
+
+const (
+	configFilename      = "config.yaml"
+	defaultHistoryLimit = 10
+)
+
+// ConfigSpec stores important information to run the vib command line.
+// The config is always stored on disk at CONFIG_DIR/vib/config.yaml.
+type ConfigSpec struct {
+	// AdmissionHooks are executables reviewing the resources before they are created, updated or
+	// deleted. Hooks run in order.
+	AdmissionHooks []admissionadapter.Config `json:"admissionHooks,omitempty"`
+	// HistoryLimit is the number of previous revisions kept for each resource.
+	// History is disabled if set to 0. Defaults to 10.
+	HistoryLimit *int `json:"historyLimit,omitempty"`
+	// StorageEncoding is the encoding of the stored resources. Must be one of json, jsonc, toml
+	// or yaml. Defaults to yaml.
+	StorageEncoding types.Encoding `json:"storageEncoding,omitempty"`
+}
+
+// LoadConfig reads the config stored in the vib config dir. Default values are returned if the
+// config file does not exist.
+func LoadConfig(vibConfigDir string) (ConfigSpec, error) {
+	out := ConfigSpec{}
+
+	path := filepath.Join(vibConfigDir, configFilename)
+	b, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return ConfigSpec{}, err
+	}
+
+	if err == nil {
+		if err := codecadapter.NewYAML().Unmarshal(b, &out); err != nil {
+			return ConfigSpec{}, flaterrors.Join(err, types.ErrVal, fmt.Errorf("invalid config %q", path))
+		}
+	}
+
+	if out.HistoryLimit == nil {
+		historyLimit := defaultHistoryLimit
+		out.HistoryLimit = &historyLimit
+	}
+
+	if out.StorageEncoding == "" {
+		out.StorageEncoding = defaultStorageEncoding
+	}
+
+	return out, nil
+}
+
+// NewAdmissionHooks returns the admission hooks of the config.
+func NewAdmissionHooks(
+	config ConfigSpec,
+	decoder types.DynamicDecoder[types.APIVersionKind],
+) ([]types.AdmissionHook, error) {
+	out := make([]types.AdmissionHook, 0, len(config.AdmissionHooks))
+	for _, hookConfig := range config.AdmissionHooks {
+		hook, err := admissionadapter.NewExec(hookConfig, decoder)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, hook)
+	}
+
+	return out, nil
+}
+
+// SetConfigValue sets a top-level value of the config file, preserving its comments and formatting.
+// The config file is created if it does not exist.
+func SetConfigValue(vibConfigDir, key, value string) error {
+	path := filepath.Join(vibConfigDir, configFilename)
+	b, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	node := new(yaml.Node)
+	if len(bytes.TrimSpace(b)) > 0 {
+		if err := yaml.Unmarshal(b, node); err != nil {
+			return flaterrors.Join(err, types.ErrVal, fmt.Errorf("invalid config %q", path))
+		}
+	}
+
+	if node.Kind == 0 {
+		node = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+
+	root := node.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return flaterrors.Join(errors.New("config must be a mapping"), types.ErrVal)
+	}
+
+	valueNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+
+	found := false
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == key {
+			valueNode.LineComment = root.Content[i+1].LineComment
+			root.Content[i+1] = valueNode
+			found = true
+		}
+	}
+
+	if !found {
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, valueNode)
+	}
+
+	buf := new(bytes.Buffer)
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(node); err != nil {
+		return err
+	}
+
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, buf.Bytes(), 0640)
+}
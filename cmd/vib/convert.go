@@ -0,0 +1,140 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+)
+
+const convertDesc = `
+	Usage:
+		vib convert [flags]
+	Description:
+		Convert the resources of the provided file to another APIVersion of
+		their kind, and print them. Resources are converted to the preferred
+		version of their kind if "-to" is not set. The file is left unchanged.`
+
+// NewConvert creates a new "convert" command.
+func NewConvert(
+	apiServer types.APIServer,
+	decoder types.DynamicDecoder[types.APIVersionKind],
+) Command {
+	out := &convert{
+		apiServer: apiServer,
+		decoder:   decoder,
+		filePath:  "",
+		fs:        flag.NewFlagSet("convert", flag.ExitOnError),
+		outputEnc: "",
+		to:        "",
+	}
+
+	out.fs.StringVar(
+		&out.filePath,
+		"f",
+		"",
+		`The name of the file to convert. Users may use "-" to read from Stdin`,
+	)
+
+	out.fs.StringVar(
+		&out.to,
+		"to",
+		"",
+		"The APIVersion the resources are converted to. Defaults to the preferred version of their kind",
+	)
+
+	NewOutputEncodingFlag(out.fs, &out.outputEnc)
+
+	return out
+}
+
+// convert holds the dependencies and flags for the "convert" command.
+type convert struct {
+	apiServer types.APIServer
+	decoder   types.DynamicDecoder[types.APIVersionKind]
+	filePath  string
+	fs        *flag.FlagSet
+	outputEnc string
+	to        types.APIVersion
+}
+
+// Description implements the Command interface.
+func (c *convert) Description() string {
+	return convertDesc
+}
+
+// FS implements the Command interface.
+func (c *convert) FS() *flag.FlagSet {
+	return c.fs
+}
+
+// Run implements the Command interface.
+func (c *convert) Run() error {
+	filePath := c.filePath
+	if filePath == "" {
+		return flaterrors.Join(
+			errors.New(`a valid file must be provided using the "-f" flag`),
+			errUsage,
+		)
+	}
+
+	if filePath == "-" {
+		filePath = os.Stdin.Name()
+	}
+
+	outputCodec, err := NewCodec(types.Encoding(c.outputEnc))
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint: errcheck
+
+	// -- decoded resources are already converted to the preferred version of their kind.
+	list, err := c.decoder.Decode(f)
+	if err != nil {
+		return err
+	}
+
+	for i := range list {
+		if list[i], err = c.apiServer.Convert(list[i], c.to); err != nil {
+			return err
+		}
+	}
+
+	// hack to better print a single resource
+	var v any = types.NewList(list)
+	if len(list) == 1 {
+		v = list[0]
+	}
+
+	b, err := outputCodec.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(b))
+
+	return nil
+}
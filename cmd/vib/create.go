@@ -45,12 +45,14 @@ func NewCreate(
 		fs:         flag.NewFlagSet("create", flag.ExitOnError),
 		namespace:  "",
 		outputEnc:  "",
+		refPolicy:  "",
 		storage:    storage,
 	}
 
 	NewAPIVersionFlag(out.fs, &out.apiVersion)
 	NewNamespaceFlag(out.fs, &out.namespace)
 	NewOutputEncodingFlag(out.fs, &out.outputEnc)
+	NewRefPolicyFlag(out.fs, &out.refPolicy)
 
 	return out
 }
@@ -62,6 +64,7 @@ type create struct {
 	fs         *flag.FlagSet
 	namespace  string
 	outputEnc  string
+	refPolicy  string
 	storage    types.Storage
 }
 
@@ -85,7 +88,7 @@ func (g *create) Run() error {
 	if g.fs.NArg() < 2 {
 		return flaterrors.Join(
 			errors.New("\"CREATE\" expects TWO argument"),
-			errors.New(createDesc), //nolint staticcheck
+			errUsage,
 		)
 	}
 
@@ -101,7 +104,11 @@ func (g *create) Run() error {
 	res.Metadata.Name = name
 	res.Metadata.Namespace = g.namespace
 
-	if err := types.ValidateResource(res); err != nil {
+	if err := g.apiServer.Validate(res); err != nil {
+		return err
+	}
+
+	if err := ValidateReferences(g.storage, []types.Resource[types.APIVersionKind]{res}, g.refPolicy); err != nil {
 		return err
 	}
 
@@ -118,7 +125,7 @@ func (g *create) Run() error {
 	)
 
 	nameFilter := map[string]struct{}{name: {}}
-	list, err := List(g.storage, res.APIVersion, types.Kind(g.fs.Arg(0)), nameFilter, g.namespace)
+	list, err := List(g.storage, res.APIVersion, res.Kind, nameFilter, g.namespace)
 	if err != nil {
 		return err
 	}
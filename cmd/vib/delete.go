@@ -18,6 +18,7 @@ package main
 import (
 	"errors"
 	"flag"
+	"fmt"
 	"log/slog"
 
 	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
@@ -28,7 +29,9 @@ const deleteDesc = `
 	Usage:
 		vib delete [flags] KIND NAME [NAME0] [NAME1]
 	Description:
-		Delete a new empty resource with the provided name.
+		Delete resources with the provided names. Resources still referenced by
+		other resources are not deleted, unless "-force" is set. If "-cascade"
+		is set, the resources referencing them are deleted as well.
 	Args:
 		KIND: the kind of the resource to delete.
 		NAME [NAME{X}]: name(s) of resources to delete.`
@@ -41,6 +44,8 @@ func NewDelete(
 	out := &del{
 		apiServer:  apiServer,
 		apiVersion: "",
+		cascade:    false,
+		force:      false,
 		fs:         flag.NewFlagSet("delete", flag.ExitOnError),
 		namespace:  "",
 		storage:    storage,
@@ -49,6 +54,9 @@ func NewDelete(
 	NewAPIVersionFlag(out.fs, &out.apiVersion)
 	NewNamespaceFlag(out.fs, &out.namespace)
 
+	out.fs.BoolVar(&out.cascade, "cascade", false, "Also delete the resources referencing the deleted resources")
+	out.fs.BoolVar(&out.force, "force", false, "Delete resources even if they are referenced by other resources")
+
 	return out
 }
 
@@ -56,6 +64,8 @@ func NewDelete(
 type del struct {
 	apiServer  types.APIServer
 	apiVersion types.APIVersion
+	cascade    bool
+	force      bool
 	fs         *flag.FlagSet
 	namespace  string
 	storage    types.Storage
@@ -76,15 +86,10 @@ func (d *del) Run() error {
 	if d.fs.NArg() < 2 {
 		return flaterrors.Join(
 			errors.New("\"DELETE\" expects at least TWO argument"),
-			errors.New(createDesc), //nolint staticcheck
+			errUsage,
 		)
 	}
 
-	names := make([]string, d.fs.NArg()-1)
-	for i := 1; i < d.fs.NArg(); i++ {
-		names[i-1] = d.fs.Arg(i)
-	}
-
 	kind := d.fs.Arg(0)
 	avk := types.NewAPIVersionKind(d.apiVersion, kind)
 	// The input apiVersion might be an empty string.
@@ -94,25 +99,72 @@ func (d *del) Run() error {
 		return err
 	}
 
-	specificAvk := types.NewAVKFromResource(res)
-	for _, name := range names {
-		nsName := types.NamespacedName{
-			Name:      name,
-			Namespace: d.namespace,
+	refs := make([]types.Reference, 0, d.fs.NArg()-1)
+	for i := 1; i < d.fs.NArg(); i++ {
+		refs = append(refs, types.Reference{
+			APIVersion:     res.APIVersion,
+			Kind:           res.Kind,
+			NamespacedName: types.NamespacedName{Name: d.fs.Arg(i), Namespace: d.namespace},
+		})
+	}
+
+	index, err := types.NewReferenceIndex(d.apiServer, d.storage)
+	if err != nil {
+		return err
+	}
+
+	if d.cascade {
+		refs = index.WithDependents(refs)
+	} else if err := checkReferencedBy(index, refs); err != nil {
+		if !d.force {
+			return flaterrors.Join(
+				err,
+				errors.New(`use "-force" to delete them anyway, or "-cascade" to also delete the resources referencing them`),
+			)
 		}
 
-		if err := d.storage.Delete(specificAvk, nsName); err != nil {
+		slog.Warn(err.Error())
+	}
+
+	for _, ref := range refs {
+		if err := d.storage.Delete(types.NewAPIVersionKind(ref.APIVersion, ref.Kind), ref.NamespacedName); err != nil {
 			return err
 		}
 
 		slog.Info(
 			"Successfully deleted resource",
-			"name", name,
-			"apiVersion", specificAvk.APIVersion(),
-			"kind", specificAvk.Kind(),
-			"namespace", res.Metadata.Namespace,
+			"name", ref.NamespacedName.Name,
+			"apiVersion", ref.APIVersion,
+			"kind", ref.Kind,
+			"namespace", ref.NamespacedName.Namespace,
 		)
 	}
 
 	return nil
 }
+
+// checkReferencedBy returns an error listing the resources referencing refs, except refs
+// themselves.
+func checkReferencedBy(index types.ReferenceIndex, refs []types.Reference) error {
+	deleted := make(map[types.Reference]struct{}, len(refs))
+	for _, ref := range refs {
+		deleted[ref] = struct{}{}
+	}
+
+	var errs error
+	for _, ref := range refs {
+		for _, dependent := range index.ReferencedBy(ref) {
+			if _, ok := deleted[dependent]; ok {
+				continue
+			}
+
+			errs = flaterrors.Join(errs, fmt.Errorf("%s is referenced by %s", ref, dependent))
+		}
+	}
+
+	if errs != nil {
+		return flaterrors.Join(errs, types.ErrRef)
+	}
+
+	return nil
+}
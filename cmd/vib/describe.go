@@ -0,0 +1,240 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
+	codecadapter "github.com/alexandremahdhaoui/vib/internal/adapter/codec"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+)
+
+const describeDesc = `
+	Usage:
+		vib describe [flags] KIND NAME
+	Description:
+		Show the metadata and the spec of a resource, the resources it
+		references with their resolution status, and the resources referencing
+		it. Renderable resources, e.g. ExpressionSets, are shown with a preview
+		of their rendered output.
+	Args:
+		KIND: the kind of the resource.
+		NAME: name of the resource.`
+
+// describePreviewLines is the maximum number of rendered lines shown by the "describe" command.
+const describePreviewLines = 10
+
+// NewDescribe creates a new "describe" command.
+func NewDescribe(apiServer types.APIServer, storage types.Storage) Command {
+	out := &describe{
+		apiServer:  apiServer,
+		apiVersion: "",
+		fs:         flag.NewFlagSet("describe", flag.ExitOnError),
+		namespace:  "",
+		storage:    storage,
+	}
+
+	NewAPIVersionFlag(out.fs, &out.apiVersion)
+	NewNamespaceFlag(out.fs, &out.namespace)
+
+	return out
+}
+
+// describe holds the dependencies and flags for the "describe" command.
+type describe struct {
+	apiServer  types.APIServer
+	apiVersion types.APIVersion
+	fs         *flag.FlagSet
+	namespace  string
+	storage    types.Storage
+}
+
+// Description implements the Command interface.
+func (d *describe) Description() string {
+	return describeDesc
+}
+
+// FS implements the Command interface.
+func (d *describe) FS() *flag.FlagSet {
+	return d.fs
+}
+
+// Run implements the Command interface.
+func (d *describe) Run() error {
+	if d.fs.NArg() != 2 {
+		return flaterrors.Join(
+			errors.New("\"DESCRIBE\" expects TWO arguments"),
+			errUsage,
+		)
+	}
+
+	// The input apiVersion might be an empty string.
+	// This ensure the apiVersion is specified
+	res, err := d.apiServer.Get(types.NewAPIVersionKind(d.apiVersion, d.fs.Arg(0)))
+	if err != nil {
+		return err
+	}
+
+	nsName := types.NamespacedName{Name: d.fs.Arg(1), Namespace: d.namespace}
+
+	res, err = d.storage.Get(types.NewAVKFromResource(res), nsName)
+	if err != nil {
+		return err
+	}
+
+	index, err := types.NewReferenceIndex(d.apiServer, d.storage)
+	if err != nil {
+		return err
+	}
+
+	w := os.Stdout
+
+	// -- metadata
+	for _, field := range [][2]string{
+		{"Name", res.Metadata.Name},
+		{"Namespace", res.Metadata.Namespace},
+		{"Kind", res.Kind},
+		{"API Version", res.APIVersion},
+		{"Resource Version", res.Metadata.ResourceVersion},
+		{"Labels", describeMap(res.Metadata.Labels)},
+		{"Annotations", describeMap(res.Metadata.Annotations)},
+	} {
+		fmt.Fprintf(w, "%-18s%s\n", field[0]+":", field[1]) //nolint: errcheck
+	}
+
+	// -- spec
+	b, err := codecadapter.NewYAML().Marshal(res.Spec)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, "\nSpec:")                                     //nolint: errcheck
+	fmt.Fprint(w, indentLines(strings.TrimSpace(string(b)), "  ")) //nolint: errcheck
+
+	// -- references
+	fmt.Fprintln(w, "\nReferences:") //nolint: errcheck
+
+	if n := d.writeReferences(w, res, "  ", make(map[types.Reference]struct{})); n == 0 {
+		fmt.Fprintln(w, "  <none>") //nolint: errcheck
+	}
+
+	fmt.Fprintln(w, "\nReferenced By:") //nolint: errcheck
+
+	referencedBy := index.ReferencedBy(types.NewReferenceFromResource(res))
+	for _, ref := range referencedBy {
+		fmt.Fprintf(w, "  %s\n", ref) //nolint: errcheck
+	}
+
+	if len(referencedBy) == 0 {
+		fmt.Fprintln(w, "  <none>") //nolint: errcheck
+	}
+
+	// -- preview
+	renderer, ok := res.Spec.(types.Renderer)
+	if !ok {
+		return nil
+	}
+
+	fmt.Fprintln(w, "\nPreview:") //nolint: errcheck
+
+	out, err := renderer.Render(d.storage)
+	if err != nil {
+		fmt.Fprintf(w, "  <error: %s>\n", strings.ReplaceAll(err.Error(), "\n", "; ")) //nolint: errcheck
+		return nil
+	}
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) > describePreviewLines {
+		lines = append(
+			lines[:describePreviewLines],
+			fmt.Sprintf("... (%d more lines)", len(lines)-describePreviewLines),
+		)
+	}
+
+	fmt.Fprint(w, indentLines(strings.Join(lines, "\n"), "  ")) //nolint: errcheck
+
+	return nil
+}
+
+// writeReferences writes the resources referenced by res with their resolution status, followed by
+// the resources they reference, and returns the number of written references.
+func (d *describe) writeReferences(
+	w io.Writer,
+	res types.Resource[types.APIVersionKind],
+	indent string,
+	visited map[types.Reference]struct{},
+) int {
+	lister, ok := res.Spec.(types.ReferenceLister)
+	if !ok {
+		return 0
+	}
+
+	visited[types.NewReferenceFromResource(res)] = struct{}{}
+
+	refs := lister.References()
+	for _, ref := range refs {
+		referenced, err := d.storage.Get(types.NewAPIVersionKind(ref.APIVersion, ref.Kind), ref.NamespacedName)
+
+		status := "found"
+		if errors.Is(err, types.ErrNotFound) {
+			status = "not found"
+		} else if err != nil {
+			status = fmt.Sprintf("error: %s", strings.ReplaceAll(err.Error(), "\n", "; "))
+		}
+
+		fmt.Fprintf(w, "%s%s (%s)\n", indent, ref, status) //nolint: errcheck
+
+		if _, ok := visited[ref]; ok || err != nil {
+			continue
+		}
+
+		d.writeReferences(w, referenced, indent+"  ", visited)
+	}
+
+	return len(refs)
+}
+
+// describeMap returns a human-readable representation of a map, sorted by key.
+func describeMap(m map[string]string) string {
+	if len(m) == 0 {
+		return "<none>"
+	}
+
+	pairs := make([]string, 0, len(m))
+	for _, key := range slices.Sorted(maps.Keys(m)) {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", key, m[key]))
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+// indentLines prefixes each line of s with indent, and terminates them with a newline.
+func indentLines(s, indent string) string {
+	buf := new(strings.Builder)
+	for _, line := range strings.Split(s, "\n") {
+		buf.WriteString(indent + line + "\n")
+	}
+
+	return buf.String()
+}
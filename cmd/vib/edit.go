@@ -16,11 +16,13 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
 	"errors"
 	"flag"
 	"fmt"
 	"log/slog"
 	"os"
+	"strings"
 
 	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
 	"github.com/alexandremahdhaoui/vib/internal/types"
@@ -31,7 +33,14 @@ const editDesc = `
 	Usage:
 		vib edit [flags] KIND NAME [NAME0] [NAME1]
 	Description:
-		Edit resources interactively.
+		Edit resources interactively. All resources are edited in a single file,
+		and are only updated once every edited resource is valid. Resources
+		removed from the file are ignored. Several resources are edited as a List,
+		unless the output encoding is yaml.
+		If an edited resource is invalid, or references a resource that does not
+		exist, the editor is reopened with the errors rendered as comments.
+		Saving an empty or unchanged file cancels the edit. Saving a rejected
+		file unchanged gives up and writes it to a recovery file.
 	Args:
 		KIND: the kind of the resource.
 		NAME [NAME{X}]: name of resource(s) to edit.`
@@ -39,21 +48,25 @@ const editDesc = `
 // NewEdit creates a new "edit" command.
 func NewEdit(
 	apiServer types.APIServer,
+	decoder types.DynamicDecoder[types.APIVersionKind],
 	storage types.Storage,
 ) Command {
 	out := &edit{
 		apiServer:  apiServer,
 		apiVersion: "",
+		decoder:    decoder,
 		editor:     "",
 		fs:         flag.NewFlagSet("edit", flag.ExitOnError),
 		namespace:  "",
 		outputEnc:  "",
+		refPolicy:  "",
 		storage:    storage,
 	}
 
 	NewAPIVersionFlag(out.fs, &out.apiVersion)
 	NewNamespaceFlag(out.fs, &out.namespace)
 	NewOutputEncodingFlag(out.fs, &out.outputEnc)
+	NewRefPolicyFlag(out.fs, &out.refPolicy)
 
 	out.fs.StringVar(
 		&out.editor,
@@ -69,10 +82,12 @@ func NewEdit(
 type edit struct {
 	apiServer  types.APIServer
 	apiVersion types.APIVersion
+	decoder    types.DynamicDecoder[types.APIVersionKind]
 	editor     string
 	fs         *flag.FlagSet
 	namespace  string
 	outputEnc  string
+	refPolicy  string
 	storage    types.Storage
 }
 
@@ -89,7 +104,7 @@ func (e *edit) FS() *flag.FlagSet {
 // Run implements the Command interface.
 func (e *edit) Run() error {
 	// -- 1. List resources
-	// -- 2. For each resource: Edit
+	// -- 2. Edit resources
 	// -- 3. For each resource: Update
 	// -- 4. List resources
 	// -- 5. Print resources
@@ -97,7 +112,7 @@ func (e *edit) Run() error {
 	if e.fs.NArg() < 2 {
 		return flaterrors.Join(
 			errors.New("\"EDIT\" expects at least TWO argument"),
-			errors.New(getDesc), //nolint staticcheck
+			errUsage,
 		)
 	}
 
@@ -123,83 +138,383 @@ func (e *edit) Run() error {
 	}
 
 	// -- 1. List resources
-	list, err := List(e.storage, res.APIVersion, kind, nameFilter, e.namespace)
+	list, err := List(e.storage, res.APIVersion, res.Kind, nameFilter, e.namespace)
 	if err != nil {
 		return err
 	}
 
-	for _, res := range list {
-		// -- 2. For each resource: Edit
-		// save resource unmutable properties
-		apiVersion := res.APIVersion
-		kind := res.Kind
-		name := res.Metadata.Name
-		namespace := res.Metadata.Namespace
+	// -- 2. Edit resources
+	// -- 3. For each resource: Update
+	if err := e.editAll(outputCodec, list); err != nil {
+		return err
+	}
+
+	// -- 4. List resources
+	out, err := List(e.storage, res.APIVersion, res.Kind, nameFilter, e.namespace)
+	if err != nil {
+		return err
+	}
+
+	// hack to better print a single resource
+	var v any = types.NewList(out)
+	if len(list) == 1 {
+		v = out[0]
+	}
 
+	b, err := outputCodec.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	// -- 5. Print resources
+	fmt.Println(string(b))
+
+	return nil
+}
+
+// editAll edits the resources in a single buffer, and updates them once every edited resource is
+// valid.
+// If the buffer is invalid, the editor is reopened with the error rendered as a comment header.
+// If resources were modified concurrently, the editor is reopened with their latest version.
+// Saving an empty or unchanged file cancels the edit.
+func (e *edit) editAll(outputCodec types.Codec, list []types.Resource[types.APIVersionKind]) error {
+	for len(list) > 0 {
 		// marshal content to edit
-		bIn, err := outputCodec.Marshal(res)
+		original, err := marshalEditBuffer(outputCodec, list)
 		if err != nil {
 			return err
 		}
 
-		bOut, err := util.EditFile(e.editor, bIn, outputCodec.Encoding())
+		var (
+			buf      = original
+			edited   []types.Resource[types.APIVersionKind]
+			ignored  []types.Resource[types.APIVersionKind]
+			rejected error
+		)
+
+		for {
+			bOut, err := util.EditFile(
+				e.editor,
+				append(editHeader(rejected), buf...),
+				outputCodec.Encoding(),
+			)
+			if err != nil {
+				return err
+			}
+
+			bOut = stripEditHeader(bOut)
+
+			if len(bytes.TrimSpace(bOut)) == 0 {
+				slog.Info("Edit cancelled: file is empty")
+				return nil
+			}
+
+			// -- the user gave up fixing the rejected edit.
+			if rejected != nil && bytes.Equal(bOut, buf) {
+				path, err := writeRecoveryFile(buf, outputCodec.Encoding())
+				if err != nil {
+					return flaterrors.Join(rejected, err)
+				}
+
+				return flaterrors.Join(rejected, fmt.Errorf("edits were saved to %q", path))
+			}
+
+			if bytes.Equal(bOut, original) {
+				slog.Info("Edit cancelled: no changes")
+				return nil
+			}
+
+			buf = bOut
+
+			edited, ignored, rejected = e.decodeEditBuffer(outputCodec, list, bOut)
+			if rejected == nil {
+				break
+			}
+		}
+
+		for _, res := range ignored {
+			slog.Warn(
+				"Resource was removed from the edited file and is ignored",
+				"apiVersion", res.APIVersion,
+				"kind", res.Kind,
+				"name", res.Metadata.Name,
+				"namespace", res.Metadata.Namespace,
+			)
+		}
+
+		// -- check every resourceVersion before writing, so that a conflict does not leave the
+		// resources partially updated.
+		latest, err := e.getLatest(edited)
 		if err != nil {
 			return err
 		}
 
-		// unmarshal edited content
-		if err := outputCodec.Unmarshal(bOut, &res); err != nil {
-			return err
+		if stale := staleResources(edited, latest); len(stale) > 0 {
+			slog.Warn(
+				"Resources have been modified concurrently; reopening the editor with their latest version",
+				"resources", stale,
+			)
+
+			list = latest
+
+			continue
+		}
+
+		list = nil
+		updated := make([]string, 0, len(edited))
+		for i, res := range edited {
+			err := e.storage.Update(res)
+			if errors.Is(err, types.ErrConflict) {
+				// -- the resource was modified after the check above.
+				slog.Warn(
+					"Resources have been modified concurrently; reopening the editor with their latest version",
+					"resource", editKey(res),
+					"updated", updated,
+				)
+
+				// -- reopen the editor with the resources that were not updated.
+				if list, err = e.getLatest(edited[i:]); err != nil {
+					return err
+				}
+
+				break
+			} else if err != nil {
+				return flaterrors.Join(err, fmt.Errorf("resources already updated: %v", updated))
+			}
+
+			updated = append(updated, editKey(res))
+
+			slog.Info(
+				"Successfully updated resource",
+				"apiVersion", res.APIVersion,
+				"kind", res.Kind,
+				"name", res.Metadata.Name,
+				"namespace", res.Metadata.Namespace,
+			)
+		}
+	}
+
+	return nil
+}
+
+// decodeEditBuffer decodes and validates the edited buffer. Every decoded resource is matched with
+// the resource of list sharing its apiVersion, kind, namespace and name.
+// It returns the modified resources, and the resources of list that were removed from the buffer.
+func (e *edit) decodeEditBuffer(
+	codec types.Codec,
+	list []types.Resource[types.APIVersionKind],
+	b []byte,
+) (edited, ignored []types.Resource[types.APIVersionKind], err error) {
+	decoded, err := e.decoder.Decode(bytes.NewReader(b))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byKey := make(map[string]types.Resource[types.APIVersionKind], len(list))
+	for _, res := range list {
+		byKey[editKey(res)] = res
+	}
+
+	seen := make(map[string]struct{}, len(decoded))
+	for i, res := range decoded {
+		key := editKey(res)
+
+		original, ok := byKey[key]
+		if !ok {
+			return nil, nil, flaterrors.Join(
+				fmt.Errorf("unexpected resource %q", key),
+				errors.New(`"apiVersion", "kind", "name" and "namespace" are immutable`),
+				types.ErrAtIndex(i),
+			)
+		}
+
+		if _, ok := seen[key]; ok {
+			return nil, nil, flaterrors.Join(
+				fmt.Errorf("duplicate resource %q", key),
+				types.ErrAtIndex(i),
+			)
 		}
+		seen[key] = struct{}{}
 
 		// -- validate resource
-		if err := types.ValidateResource(res); err != nil {
-			return err
+		if err := e.apiServer.Validate(res); err != nil {
+			return nil, nil, flaterrors.Join(err, types.ErrAtIndex(i))
 		}
 
-		// -- validate apiVersion, kind, name and namespace are unchanged.
-		if res.APIVersion != apiVersion ||
-			res.Kind != kind ||
-			res.Metadata.Name != name ||
-			res.Metadata.Namespace != namespace {
-			return errors.New(`"apiVersion", "kind", "name" and "namespace" are immutable`)
+		// -- the namespace may be omitted, and the resourceVersion must not be altered by the user.
+		res.Metadata.Namespace = original.Metadata.Namespace
+		res.Metadata.ResourceVersion = original.Metadata.ResourceVersion
+
+		// -- skip unchanged resources.
+		if unchanged, err := resourceEqual(codec, res, original); err != nil {
+			return nil, nil, err
+		} else if unchanged {
+			continue
 		}
 
-		// -- 3. For each resource: Update
-		if err := e.storage.Update(res); err != nil {
-			return err
+		edited = append(edited, res)
+	}
+
+	for _, res := range list {
+		if _, ok := seen[editKey(res)]; !ok {
+			ignored = append(ignored, res)
 		}
+	}
+
+	if err := ValidateReferences(e.storage, edited, e.refPolicy); err != nil {
+		return nil, nil, err
+	}
+
+	return edited, ignored, nil
+}
 
-		slog.Info(
-			"Successfully updated resource",
-			"apiVersion", apiVersion,
-			"kind", kind,
-			"name", name,
-			"namespace", res.Metadata.Namespace,
+// getLatest gets the latest version of the resources from storage.
+func (e *edit) getLatest(
+	list []types.Resource[types.APIVersionKind],
+) ([]types.Resource[types.APIVersionKind], error) {
+	out := make([]types.Resource[types.APIVersionKind], 0, len(list))
+	for _, res := range list {
+		latest, err := e.storage.Get(
+			types.NewAVKFromResource(res),
+			types.NewNamespacedNameFromMetadata(res.Metadata),
 		)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, latest)
 	}
 
-	// -- 4. List resources
-	out, err := List(e.storage, res.APIVersion, kind, nameFilter, e.namespace)
+	return out, nil
+}
+
+// marshalEditBuffer marshals the resources into a single buffer. YAML resources are concatenated
+// documents. Other encodings do not support several documents in a single file, thus several
+// resources are marshaled as a List.
+func marshalEditBuffer(
+	codec types.Codec,
+	list []types.Resource[types.APIVersionKind],
+) ([]byte, error) {
+	if len(list) > 1 && codec.Encoding() != types.YAMLEncoding {
+		b, err := codec.Marshal(types.NewList(list))
+		if err != nil {
+			return nil, err
+		}
+
+		if !bytes.HasSuffix(b, []byte("\n")) {
+			b = append(b, '\n')
+		}
+
+		return b, nil
+	}
+
+	buf := new(bytes.Buffer)
+	for i, res := range list {
+		b, err := codec.Marshal(res)
+		if err != nil {
+			return nil, err
+		}
+
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+
+		buf.Write(b)
+		if !bytes.HasSuffix(b, []byte("\n")) {
+			buf.WriteString("\n")
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// resourceEqual returns true if both resources have the same encoded representation.
+func resourceEqual(codec types.Codec, a, b types.Resource[types.APIVersionKind]) (bool, error) {
+	bA, err := codec.Marshal(a)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	// hack to better print a single resource
-	var v any = out
-	if len(list) == 1 {
-		v = out[0]
+	bB, err := codec.Marshal(b)
+	if err != nil {
+		return false, err
 	}
 
-	b, err := outputCodec.Marshal(v)
+	return bytes.Equal(bA, bB), nil
+}
+
+// staleResources returns the keys of the edited resources whose resourceVersion differs from the
+// resourceVersion of their latest version.
+func staleResources(edited, latest []types.Resource[types.APIVersionKind]) []string {
+	out := make([]string, 0)
+	for i, res := range edited {
+		if res.Metadata.ResourceVersion != latest[i].Metadata.ResourceVersion {
+			out = append(out, editKey(res))
+		}
+	}
+
+	return out
+}
+
+// editKey identifies a resource by its apiVersion, kind, namespace and name.
+func editKey(res types.Resource[types.APIVersionKind]) string {
+	nsName := types.NewNamespacedNameFromMetadata(res.Metadata)
+	return fmt.Sprintf("%s/%s/%s/%s", res.APIVersion, res.Kind, nsName.Namespace, nsName.Name)
+}
+
+// editHeaderPrefix prefixes the lines of the header added on top of a rejected edit.
+// These lines are removed before decoding the edited content.
+const editHeaderPrefix = "# vib:"
+
+// editHeader renders err as a comment header. It returns nil if err is nil.
+func editHeader(err error) []byte {
+	if err == nil {
+		return nil
+	}
+
+	lines := []string{
+		"The edit was rejected with the following error(s).",
+		"Please fix them, or save the file unchanged to give up. An empty file cancels the edit.",
+		"",
+	}
+	lines = append(lines, strings.Split(strings.TrimSpace(err.Error()), "\n")...)
+
+	buf := new(bytes.Buffer)
+	for _, line := range lines {
+		buf.WriteString(strings.TrimSpace(fmt.Sprintf("%s %s", editHeaderPrefix, line)))
+		buf.WriteString("\n")
+	}
+
+	return buf.Bytes()
+}
+
+// stripEditHeader removes the header added by editHeader.
+func stripEditHeader(b []byte) []byte {
+	for bytes.HasPrefix(b, []byte(editHeaderPrefix)) {
+		i := bytes.IndexByte(b, '\n')
+		if i < 0 {
+			return nil
+		}
+
+		b = b[i+1:]
+	}
+
+	return b
+}
+
+// writeRecoveryFile saves the rejected content of an edit to a file, and returns its path.
+func writeRecoveryFile(b []byte, encoding types.Encoding) (string, error) {
+	f, err := os.CreateTemp("", fmt.Sprintf("vib-edit-recovery-*.%s", encoding))
 	if err != nil {
-		return err
+		return "", err
 	}
+	defer f.Close() //nolint: errcheck
 
-	// -- 5. Print resources
-	fmt.Println(string(b))
+	if _, err := f.Write(b); err != nil {
+		return "", err
+	}
 
-	return nil
+	return f.Name(), f.Close()
 }
 
 func getDefaultEditor() string {
@@ -0,0 +1,435 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	codecadapter "github.com/alexandremahdhaoui/vib/internal/adapter/codec"
+	storageadapter "github.com/alexandremahdhaoui/vib/internal/adapter/storage"
+	"github.com/alexandremahdhaoui/vib/internal/service"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+	"github.com/alexandremahdhaoui/vib/pkg/apis/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditHeader(t *testing.T) {
+	for _, tc := range []struct {
+		Name   string
+		Err    error
+		Expect string
+	}{
+		{
+			Name:   "NoError",
+			Err:    nil,
+			Expect: "",
+		},
+		{
+			Name: "SingleLine",
+			Err:  errors.New("invalid resource"),
+			Expect: "# vib: The edit was rejected with the following error(s).\n" +
+				"# vib: Please fix them, or save the file unchanged to give up. An empty file cancels the edit.\n" +
+				"# vib:\n" +
+				"# vib: invalid resource\n",
+		},
+		{
+			Name: "MultiLine",
+			Err:  errors.Join(errors.New("invalid resource"), errors.New("at index 1")),
+			Expect: "# vib: The edit was rejected with the following error(s).\n" +
+				"# vib: Please fix them, or save the file unchanged to give up. An empty file cancels the edit.\n" +
+				"# vib:\n" +
+				"# vib: invalid resource\n" +
+				"# vib: at index 1\n",
+		},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.Expect, string(editHeader(tc.Err)))
+		})
+	}
+}
+
+func TestStripEditHeader(t *testing.T) {
+	content := "apiVersion: vib.alexandre.mahdhaoui.com/v1alpha1\nkind: ExpressionSet\n"
+
+	for _, tc := range []struct {
+		Name   string
+		Input  string
+		Expect string
+	}{
+		{
+			Name:   "NoHeader",
+			Input:  content,
+			Expect: content,
+		},
+		{
+			Name:   "Header",
+			Input:  string(editHeader(errors.New("invalid resource"))) + content,
+			Expect: content,
+		},
+		{
+			Name:   "HeaderOnly",
+			Input:  string(editHeader(errors.New("invalid resource"))),
+			Expect: "",
+		},
+		{
+			Name:   "HeaderWithoutTrailingNewline",
+			Input:  "# vib: invalid resource",
+			Expect: "",
+		},
+		{
+			Name:   "CommentAfterContent",
+			Input:  content + "# vib: invalid resource\n",
+			Expect: content + "# vib: invalid resource\n",
+		},
+		{
+			Name:   "OtherComment",
+			Input:  "# a comment\n" + content,
+			Expect: "# a comment\n" + content,
+		},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.Expect, string(stripEditHeader([]byte(tc.Input))))
+		})
+	}
+}
+
+func TestWriteRecoveryFile(t *testing.T) {
+	for _, tc := range []struct {
+		Name     string
+		Encoding types.Encoding
+		Content  string
+	}{
+		{
+			Name:     "YAML",
+			Encoding: types.YAMLEncoding,
+			Content:  "kind: ExpressionSet\n",
+		},
+		{
+			Name:     "JSON",
+			Encoding: types.JSONEncoding,
+			Content:  `{"kind": "ExpressionSet"}`,
+		},
+		{
+			Name:     "Empty",
+			Encoding: types.YAMLEncoding,
+			Content:  "",
+		},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv("TMPDIR", dir)
+
+			path, err := writeRecoveryFile([]byte(tc.Content), tc.Encoding)
+			assert.NoError(t, err)
+
+			assert.Equal(t, dir, filepath.Dir(path))
+			assert.Equal(t, "."+string(tc.Encoding), filepath.Ext(path))
+
+			b, err := os.ReadFile(path)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Content, string(b))
+		})
+	}
+}
+
+func TestMarshalEditBuffer(t *testing.T) {
+	e, _ := newTestEdit(t)
+
+	list, err := List(e.storage, v1alpha1.APIVersion, v1alpha1.ExpressionSetKind, nil, types.DefaultNamespace)
+	assert.NoError(t, err)
+
+	for _, codec := range []types.Codec{
+		codecadapter.NewJSON(),
+		codecadapter.NewJSONC(),
+		codecadapter.NewTOML(),
+		codecadapter.NewYAML(),
+	} {
+		t.Run(string(codec.Encoding()), func(t *testing.T) {
+			for _, expected := range [][]types.Resource[types.APIVersionKind]{list[:1], list} {
+				b, err := marshalEditBuffer(codec, expected)
+				assert.NoError(t, err)
+
+				actual, err := e.decoder.Decode(bytes.NewReader(b))
+				assert.NoError(t, err)
+				assert.Len(t, actual, len(expected))
+
+				for i := range actual {
+					actual[i].SetNode(nil)
+					expected[i].SetNode(nil)
+				}
+
+				assert.Equal(t, expected, actual)
+			}
+		})
+	}
+}
+
+func TestEdit_DecodeEditBuffer(t *testing.T) {
+	e, _ := newTestEdit(t)
+	codec := codecadapter.NewYAML()
+
+	list, err := List(e.storage, v1alpha1.APIVersion, v1alpha1.ExpressionSetKind, nil, types.DefaultNamespace)
+	assert.NoError(t, err)
+
+	original, err := marshalEditBuffer(codec, list)
+	assert.NoError(t, err)
+
+	first, err := codec.Marshal(list[0])
+	assert.NoError(t, err)
+
+	for _, tc := range []struct {
+		Name          string
+		Buffer        string
+		ExpectEdited  []string
+		ExpectIgnored []string
+		ExpectErr     string
+	}{
+		{
+			Name:          "Unchanged",
+			Buffer:        string(original),
+			ExpectEdited:  nil,
+			ExpectIgnored: nil,
+		},
+		{
+			Name:          "Edited",
+			Buffer:        strings.Replace(string(original), "set -o vi", "set -o emacs", 1),
+			ExpectEdited:  []string{"first"},
+			ExpectIgnored: nil,
+		},
+		{
+			Name:          "Removed",
+			Buffer:        strings.Replace(string(first), "set -o vi", "set -o emacs", 1),
+			ExpectEdited:  []string{"first"},
+			ExpectIgnored: []string{"second"},
+		},
+		{
+			Name:      "UnexpectedResource",
+			Buffer:    strings.Replace(string(original), "name: first", "name: third", 1),
+			ExpectErr: `unexpected resource "vib.amahdha.com/v1alpha1/ExpressionSet/default/third"`,
+		},
+		{
+			Name:      "DuplicateResource",
+			Buffer:    string(original) + "---\n" + string(first),
+			ExpectErr: `duplicate resource "vib.amahdha.com/v1alpha1/ExpressionSet/default/first"`,
+		},
+		{
+			Name:      "InvalidResource",
+			Buffer:    strings.Replace(string(original), "name: plain", "name: INVALID", 1),
+			ExpectErr: "invalid resolverRef",
+		},
+		{
+			Name:      "MissingReference",
+			Buffer:    strings.Replace(string(original), "name: plain", "name: missing", 1),
+			ExpectErr: "which does not exist",
+		},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			edited, ignored, err := e.decodeEditBuffer(codec, list, []byte(tc.Buffer))
+			if tc.ExpectErr != "" {
+				assert.ErrorContains(t, err, tc.ExpectErr)
+				return
+			}
+
+			assert.NoError(t, err)
+
+			names := func(list []types.Resource[types.APIVersionKind]) []string {
+				var out []string
+				for _, res := range list {
+					out = append(out, res.Metadata.Name)
+				}
+
+				return out
+			}
+
+			assert.Equal(t, tc.ExpectEdited, names(edited))
+			assert.Equal(t, tc.ExpectIgnored, names(ignored))
+
+			// -- the resourceVersion is kept from the listed resources.
+			for _, res := range edited {
+				assert.Equal(t, "1", res.Metadata.ResourceVersion)
+			}
+		})
+	}
+}
+
+func TestEdit_EditAll(t *testing.T) {
+	list := func(t *testing.T, e *edit) []types.Resource[types.APIVersionKind] {
+		t.Helper()
+
+		out, err := List(e.storage, v1alpha1.APIVersion, v1alpha1.ExpressionSetKind, nil, types.DefaultNamespace)
+		assert.NoError(t, err)
+		assert.Len(t, out, 2)
+
+		return out
+	}
+
+	// expect asserts the resourceVersion and the first arbitrary key of each listed resource.
+	expect := func(t *testing.T, e *edit, resourceVersions []string, keys []string) {
+		t.Helper()
+
+		for i, res := range list(t, e) {
+			assert.Equal(t, resourceVersions[i], res.Metadata.ResourceVersion, res.Metadata.Name)
+			assert.Equal(t, keys[i], res.Spec.(*v1alpha1.ExpressionSetSpec).ArbitraryKeys[0], res.Metadata.Name)
+		}
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		e, _ := newTestEdit(t)
+		e.editor = newTestEditor(t, `sed -i 's/set -o vi/set -o emacs/' "$1"`)
+
+		assert.NoError(t, e.editAll(codecadapter.NewYAML(), list(t, e)))
+		expect(t, e, []string{"2", "2"}, []string{"set -o emacs", "set -o emacs"})
+	})
+
+	t.Run("TOML", func(t *testing.T) {
+		e, _ := newTestEdit(t)
+		e.editor = newTestEditor(t, `sed -i 's/set -o vi/set -o emacs/' "$1"`)
+
+		assert.NoError(t, e.editAll(codecadapter.NewTOML(), list(t, e)))
+		expect(t, e, []string{"2", "2"}, []string{"set -o emacs", "set -o emacs"})
+	})
+
+	t.Run("InvalidDocument", func(t *testing.T) {
+		// -- the first document is valid, but the second is not: nothing is written, and giving
+		// up saves the edits to a recovery file.
+		e, _ := newTestEdit(t)
+		e.editor = newTestEditor(t,
+			`sed -i -e 's/set -o vi/set -o emacs/' -e '/^---$/,$ s/name: plain/name: INVALID/' "$1"`,
+			`grep -q '^# vib: ' "$1"`,
+		)
+
+		err := e.editAll(codecadapter.NewYAML(), list(t, e))
+		assert.ErrorContains(t, err, "edits were saved to")
+		expect(t, e, []string{"1", "1"}, []string{"set -o vi", "set -o vi"})
+	})
+
+	t.Run("InvalidDocumentFixed", func(t *testing.T) {
+		e, _ := newTestEdit(t)
+		e.editor = newTestEditor(t,
+			`sed -i -e 's/set -o vi/set -o emacs/' -e '/^---$/,$ s/name: plain/name: INVALID/' "$1"`,
+			`sed -i 's/name: INVALID/name: plain/' "$1"`,
+		)
+
+		assert.NoError(t, e.editAll(codecadapter.NewYAML(), list(t, e)))
+		expect(t, e, []string{"2", "2"}, []string{"set -o emacs", "set -o emacs"})
+	})
+
+	t.Run("RemovedDocument", func(t *testing.T) {
+		e, _ := newTestEdit(t)
+		e.editor = newTestEditor(t, `sed -i -e '/^---$/,$ d' -e 's/set -o vi/set -o emacs/' "$1"`)
+
+		assert.NoError(t, e.editAll(codecadapter.NewYAML(), list(t, e)))
+		expect(t, e, []string{"2", "1"}, []string{"set -o emacs", "set -o vi"})
+	})
+
+	t.Run("StaleResource", func(t *testing.T) {
+		// -- "second" is modified while the editor is open: nothing is written, and the editor is
+		// reopened with the latest version of the resources.
+		e, dir := newTestEdit(t)
+
+		paths, err := filepath.Glob(filepath.Join(dir, types.DefaultNamespace, "*.expressionset.second.yaml"))
+		assert.NoError(t, err)
+		assert.Len(t, paths, 1)
+
+		e.editor = newTestEditor(t,
+			fmt.Sprintf(
+				`sed -i 's/set -o vi/set -o emacs/' "$1" && sed -i 's/resourceVersion: "1"/resourceVersion: "2"/' %q`,
+				paths[0],
+			),
+			`grep -q 'resourceVersion: "2"' "$1" && ! grep -q 'set -o emacs' "$1"`,
+		)
+
+		assert.NoError(t, e.editAll(codecadapter.NewYAML(), list(t, e)))
+		expect(t, e, []string{"1", "2"}, []string{"set -o vi", "set -o vi"})
+	})
+}
+
+// newTestEdit returns an "edit" command backed by a filesystem storage, and the directory of the
+// storage. The storage holds the ExpressionSets "first" and "second" in the default namespace.
+func newTestEdit(t *testing.T) (*edit, string) {
+	t.Helper()
+	t.Setenv("TMPDIR", t.TempDir())
+
+	apiServer := service.NewAPIServer()
+	v1alpha1.RegisterWithManager(apiServer)
+
+	dir := t.TempDir()
+	storage, err := storageadapter.NewFilesystem(
+		apiServer,
+		codecadapter.NewYAML(),
+		codecadapter.NewDynamicResourceDecoder(apiServer),
+		dir,
+		0,
+	)
+	assert.NoError(t, err)
+
+	resolver := v1alpha1.NewPlainResolver()
+	resolver.Metadata.Namespace = types.VibSystemNamespace
+	assert.NoError(t, storage.Create(resolver))
+
+	for _, name := range []string{"first", "second"} {
+		assert.NoError(t, storage.Create(newEditedExpressionSet(name)))
+	}
+
+	return &edit{ //nolint:exhaustruct
+		apiServer: apiServer,
+		decoder:   codecadapter.NewDynamicResourceDecoder(apiServer),
+		refPolicy: errorRefPolicy,
+		storage:   storage,
+	}, dir
+}
+
+// newEditedExpressionSet returns an ExpressionSet in the default namespace.
+func newEditedExpressionSet(name string) types.Resource[types.APIVersionKind] {
+	return types.Resource[types.APIVersionKind]{
+		APIVersion: v1alpha1.APIVersion,
+		Kind:       v1alpha1.ExpressionSetKind,
+		Metadata:   types.Metadata{Name: name, Namespace: types.DefaultNamespace},
+		Spec: &v1alpha1.ExpressionSetSpec{
+			ArbitraryKeys: []string{"set -o vi"},
+			ResolverRef: types.NamespacedName{
+				Name:      v1alpha1.PlainResolverRef,
+				Namespace: types.VibSystemNamespace,
+			},
+		},
+	}
+}
+
+// newTestEditor writes an editor script running the n-th command on its n-th invocation, and
+// leaving the file unchanged afterwards. It fails if it is invoked more often than expected.
+func newTestEditor(t *testing.T, commands ...string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	script := new(strings.Builder)
+	fmt.Fprintf(script, "#!/bin/sh\nset -e\nn=$(cat %q 2>/dev/null || echo 0)\n", filepath.Join(dir, "count"))
+	fmt.Fprintf(script, "n=$((n + 1))\necho \"$n\" > %q\ncase \"$n\" in\n", filepath.Join(dir, "count"))
+	for i, command := range commands {
+		fmt.Fprintf(script, "%d) %s ;;\n", i+1, command)
+	}
+	script.WriteString("*) exit 1 ;;\nesac\n")
+
+	path := filepath.Join(dir, "editor.sh")
+	assert.NoError(t, os.WriteFile(path, []byte(script.String()), 0o700))
+
+	return path
+}
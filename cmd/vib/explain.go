@@ -0,0 +1,266 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+)
+
+const explainDesc = `
+	Usage:
+		vib explain [flags] KIND[.FIELD.PATH]
+	Description:
+		Describe the fields of a kind, or of one of its fields, e.g.
+		"vib explain expressionset.spec.resolverRef". Each field is
+		printed with its type, whether it is required and its description.
+	Args:
+		KIND: the kind of the resource.
+		FIELD.PATH: the path of a field, separated by dots. (optional)`
+
+// explainWrapWidth is the maximum width of the descriptions printed by the "explain" command.
+const explainWrapWidth = 80
+
+// NewExplain creates a new "explain" command.
+func NewExplain(apiServer types.APIServer) Command {
+	out := &explain{
+		apiServer:  apiServer,
+		apiVersion: "",
+		fs:         flag.NewFlagSet("explain", flag.ExitOnError),
+	}
+
+	NewAPIVersionFlag(out.fs, &out.apiVersion)
+
+	return out
+}
+
+// explain holds the dependencies and flags for the "explain" command.
+type explain struct {
+	apiServer  types.APIServer
+	apiVersion types.APIVersion
+	fs         *flag.FlagSet
+}
+
+// Description implements the Command interface.
+func (e *explain) Description() string {
+	return explainDesc
+}
+
+// FS implements the Command interface.
+func (e *explain) FS() *flag.FlagSet {
+	return e.fs
+}
+
+// Run implements the Command interface.
+func (e *explain) Run() error {
+	if e.fs.NArg() != 1 {
+		return flaterrors.Join(
+			errors.New("\"EXPLAIN\" expects ONE argument"),
+			errUsage,
+		)
+	}
+
+	kind, fieldPath, _ := strings.Cut(e.fs.Arg(0), ".")
+
+	res, err := e.apiServer.Get(types.NewAPIVersionKind(e.apiVersion, kind))
+	if err != nil {
+		return err
+	}
+
+	avk := types.NewAVKFromResource(res)
+
+	schema, err := e.apiServer.Schema(avk)
+	if err != nil {
+		return err
+	}
+
+	var fieldName string
+	if fieldPath != "" {
+		if schema, fieldName, err = lookupSchemaField(schema, fieldPath); err != nil {
+			return err
+		}
+	}
+
+	w := os.Stdout
+	fmt.Fprintf(w, "KIND:     %s\nVERSION:  %s\n\n", avk.Kind(), avk.APIVersion()) //nolint: errcheck
+
+	if fieldName != "" {
+		fmt.Fprintf(w, "FIELD:    %s <%s>\n\n", fieldName, schemaTypeName(schema)) //nolint: errcheck
+	}
+
+	fmt.Fprintln(w, "DESCRIPTION:") //nolint: errcheck
+
+	description := schema.Description
+	if description == "" {
+		description = "<empty>"
+	}
+
+	writeWrapped(w, description, "    ")
+
+	if len(schema.Enum) > 0 {
+		values := make([]string, 0, len(schema.Enum))
+		for _, v := range schema.Enum {
+			values = append(values, fmt.Sprint(v))
+		}
+
+		fmt.Fprintf(w, "\nENUM:\n    %s\n", strings.Join(values, ", ")) //nolint: errcheck
+	}
+
+	// -- fields of arrays and maps are the fields of their elements.
+	schema = schemaElem(schema)
+	if len(schema.Properties) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w, "\nFIELDS:") //nolint: errcheck
+
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	for _, name := range names {
+		field := schema.Properties[name]
+
+		required := ""
+		if slices.Contains(schema.Required, name) {
+			required = " -required-"
+		}
+
+		fmt.Fprintf(w, "  %s\t<%s>%s\n", name, schemaTypeName(field), required) //nolint: errcheck
+		writeWrapped(w, field.Description, "    ")
+		fmt.Fprintln(w) //nolint: errcheck
+	}
+
+	if len(schema.OneOf) > 0 {
+		fmt.Fprintln(w, "ONE OF:") //nolint: errcheck
+
+		for _, branch := range schema.OneOf {
+			fmt.Fprintf(w, "  %s\n", describeOneOfBranch(branch)) //nolint: errcheck
+		}
+	}
+
+	return nil
+}
+
+// lookupSchemaField returns the schema of the field at fieldPath, e.g. "spec.resolverRef", and the
+// name of the field. Field names are matched case-insensitively if no field matches exactly.
+func lookupSchemaField(schema *types.Schema, fieldPath string) (*types.Schema, string, error) {
+	var name string
+	for _, name = range strings.Split(fieldPath, ".") {
+		parent := schemaElem(schema)
+
+		field, ok := parent.Properties[name]
+		if !ok {
+			for key, value := range parent.Properties {
+				if strings.EqualFold(key, name) {
+					name, field, ok = key, value, true
+					break
+				}
+			}
+		}
+
+		if !ok {
+			return nil, "", flaterrors.Join(
+				types.ErrNotFound,
+				fmt.Errorf("field %q does not exist", fieldPath),
+			)
+		}
+
+		schema = field
+	}
+
+	return schema, name, nil
+}
+
+// schemaElem returns the schema of the elements of an array or a map, or schema itself.
+func schemaElem(schema *types.Schema) *types.Schema {
+	for {
+		if schema.Items != nil {
+			schema = schema.Items
+		} else if elem, ok := schema.AdditionalProperties.(*types.Schema); ok {
+			schema = elem
+		} else {
+			return schema
+		}
+	}
+}
+
+// schemaTypeName returns a human-readable name of the type of schema, e.g. "[]string".
+func schemaTypeName(schema *types.Schema) string {
+	if schema.Items != nil {
+		return "[]" + schemaTypeName(schema.Items)
+	}
+
+	if elem, ok := schema.AdditionalProperties.(*types.Schema); ok {
+		return "map[string]" + schemaTypeName(elem)
+	}
+
+	return schema.Type
+}
+
+// describeOneOfBranch describes a branch of a one-of constraint, e.g. "type=exec requires exec".
+func describeOneOfBranch(branch *types.Schema) string {
+	conditions := make([]string, 0)
+	for name, property := range branch.Properties {
+		if property.Const != nil {
+			conditions = append(conditions, fmt.Sprintf("%s=%v", name, property.Const))
+		}
+	}
+
+	slices.Sort(conditions)
+
+	required := slices.DeleteFunc(slices.Clone(branch.Required), func(name string) bool {
+		property, ok := branch.Properties[name]
+		return ok && property.Const != nil
+	})
+
+	return fmt.Sprintf("%s requires %s", strings.Join(conditions, ","), strings.Join(required, ","))
+}
+
+// writeWrapped writes text to w, wrapping its lines at explainWrapWidth and prefixing them with
+// indent.
+func writeWrapped(w io.Writer, text, indent string) {
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := indent
+		for _, word := range strings.Fields(paragraph) {
+			if len(line) > len(indent) && len(line)+1+len(word) > explainWrapWidth {
+				fmt.Fprintln(w, line) //nolint: errcheck
+				line = indent
+			}
+
+			if len(line) > len(indent) {
+				line += " "
+			}
+
+			line += word
+		}
+
+		if len(line) > len(indent) {
+			fmt.Fprintln(w, line) //nolint: errcheck
+		}
+	}
+}
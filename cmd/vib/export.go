@@ -0,0 +1,180 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+
+	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
+	bundleadapter "github.com/alexandremahdhaoui/vib/internal/adapter/bundle"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+)
+
+const exportDesc = `
+	Usage:
+		vib export [flags]
+	Description:
+		Export all resources of a namespace into a portable bundle (.tar.gz).
+		Resources of the "vib-system" namespace are included when they are
+		referenced by exported resources.`
+
+// NewExport creates a new "export" command.
+func NewExport(
+	apiServer types.APIServer,
+	storage types.Storage,
+) Command {
+	out := &export{
+		apiServer: apiServer,
+		filePath:  "",
+		fs:        flag.NewFlagSet("export", flag.ExitOnError),
+		namespace: "",
+		storage:   storage,
+	}
+
+	NewNamespaceFlag(out.fs, &out.namespace)
+
+	out.fs.StringVar(
+		&out.filePath,
+		"o",
+		"",
+		`The name of the bundle to write. Users may use "-" to write to Stdout`,
+	)
+
+	return out
+}
+
+// export holds the dependencies and flags for the "export" command.
+type export struct {
+	apiServer types.APIServer
+	filePath  string
+	fs        *flag.FlagSet
+	namespace string
+	storage   types.Storage
+}
+
+// Description implements the Command interface.
+func (e *export) Description() string {
+	return exportDesc
+}
+
+// FS implements the Command interface.
+func (e *export) FS() *flag.FlagSet {
+	return e.fs
+}
+
+// Run implements the Command interface.
+func (e *export) Run() error {
+	if e.filePath == "" {
+		return flaterrors.Join(
+			errors.New(`a valid file must be provided using the "-o" flag`),
+			errUsage,
+		)
+	}
+
+	outputCodec, err := NewCodec(defaultOutputEncoding)
+	if err != nil {
+		return err
+	}
+
+	resources := make([]types.Resource[types.APIVersionKind], 0)
+	for _, avk := range e.apiServer.List() {
+		list, err := e.storage.List(avk, e.namespace)
+		if err != nil {
+			return err
+		}
+
+		resources = append(resources, list...)
+	}
+
+	systemResources, err := referencedSystemResources(e.storage, resources)
+	if err != nil {
+		return err
+	}
+
+	resources = append(resources, systemResources...)
+
+	var w io.Writer = os.Stdout
+	if e.filePath != "-" {
+		f, err := os.Create(e.filePath)
+		if err != nil {
+			return err
+		}
+		defer f.Close() //nolint: errcheck
+
+		w = f
+	}
+
+	if err := bundleadapter.Write(w, outputCodec, resources); err != nil {
+		return err
+	}
+
+	slog.Info(
+		"Successfully exported resources",
+		"namespace", e.namespace,
+		"count", len(resources),
+		"file", e.filePath,
+	)
+
+	return nil
+}
+
+// referencedSystemResources returns the resources of the "vib-system" namespace referenced by the
+// provided resources, directly or through other system resources, e.g. the Resolver of a system
+// ExpressionSet referenced by a Profile. System resources already part of the provided resources are
+// not returned.
+func referencedSystemResources(
+	storage types.Storage,
+	resources []types.Resource[types.APIVersionKind],
+) ([]types.Resource[types.APIVersionKind], error) {
+	visited := make(map[types.Reference]struct{})
+	for _, res := range resources {
+		visited[types.NewReferenceFromResource(res)] = struct{}{}
+	}
+
+	out := make([]types.Resource[types.APIVersionKind], 0)
+	for queue := slices.Clone(resources); len(queue) > 0; queue = queue[1:] {
+		lister, ok := queue[0].Spec.(types.ReferenceLister)
+		if !ok {
+			continue
+		}
+
+		for _, ref := range lister.References() {
+			if ref.NamespacedName.Namespace != types.VibSystemNamespace {
+				continue
+			}
+
+			if _, ok := visited[ref]; ok {
+				continue
+			}
+
+			system, err := storage.Get(types.NewAPIVersionKind(ref.APIVersion, ref.Kind), ref.NamespacedName)
+			if err != nil {
+				return nil, err
+			}
+
+			visited[ref] = struct{}{}
+			out = append(out, system)
+			queue = append(queue, system)
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"testing"
+
+	codecadapter "github.com/alexandremahdhaoui/vib/internal/adapter/codec"
+	storageadapter "github.com/alexandremahdhaoui/vib/internal/adapter/storage"
+	"github.com/alexandremahdhaoui/vib/internal/service"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+	"github.com/alexandremahdhaoui/vib/pkg/apis/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReferencedSystemResources(t *testing.T) {
+	apiServer := service.NewAPIServer()
+	v1alpha1.RegisterWithManager(apiServer)
+
+	storage, err := storageadapter.NewFilesystem(
+		apiServer,
+		codecadapter.NewYAML(),
+		codecadapter.NewDynamicResourceDecoder(apiServer),
+		t.TempDir(),
+		0,
+	)
+	assert.NoError(t, err)
+
+	// -- Profile (default) -> ExpressionSet (vib-system) -> Resolver (vib-system).
+	resolver := v1alpha1.NewPlainResolver()
+	resolver.Metadata = types.Metadata{Name: "custom", Namespace: types.VibSystemNamespace}
+
+	expressionSet := types.Resource[types.APIVersionKind]{
+		APIVersion: v1alpha1.APIVersion,
+		Kind:       v1alpha1.ExpressionSetKind,
+		Metadata:   types.Metadata{Name: "system", Namespace: types.VibSystemNamespace},
+		Spec: &v1alpha1.ExpressionSetSpec{
+			ArbitraryKeys: []string{"set -o vi"},
+			ResolverRef:   types.NamespacedName{Name: "custom", Namespace: types.VibSystemNamespace},
+		},
+	}
+
+	profile := types.Resource[types.APIVersionKind]{
+		APIVersion: v1alpha1.APIVersion,
+		Kind:       v1alpha1.ProfileKind,
+		Metadata:   types.Metadata{Name: "profile", Namespace: types.DefaultNamespace},
+		Spec: &v1alpha1.ProfileSpec{Refs: []types.NamespacedName{
+			{Name: "system", Namespace: types.VibSystemNamespace},
+		}},
+	}
+
+	for _, res := range []types.Resource[types.APIVersionKind]{resolver, expressionSet, profile} {
+		assert.NoError(t, storage.Create(res))
+	}
+
+	got, err := referencedSystemResources(storage, []types.Resource[types.APIVersionKind]{profile})
+	assert.NoError(t, err)
+
+	refs := make([]types.Reference, 0, len(got))
+	for _, res := range got {
+		refs = append(refs, types.NewReferenceFromResource(res))
+	}
+
+	assert.Equal(t, []types.Reference{
+		types.NewReferenceFromResource(expressionSet),
+		types.NewReferenceFromResource(resolver),
+	}, refs)
+
+	// -- exported system resources are not returned twice.
+	got, err = referencedSystemResources(storage, []types.Resource[types.APIVersionKind]{profile, expressionSet})
+	assert.NoError(t, err)
+	assert.Len(t, got, 1)
+}
@@ -77,7 +77,7 @@ func (g *get) Run() error {
 	if g.fs.NArg() < 1 {
 		return flaterrors.Join(
 			errors.New("\"GET\" expects at least ONE argument"),
-			errors.New(getDesc), //nolint staticcheck
+			errUsage,
 		)
 	}
 
@@ -102,13 +102,13 @@ func (g *get) Run() error {
 		nameFilter[g.fs.Arg(i)] = struct{}{}
 	}
 
-	list, err := List(g.storage, res.APIVersion, kind, nameFilter, g.namespace)
+	list, err := List(g.storage, res.APIVersion, res.Kind, nameFilter, g.namespace)
 	if err != nil {
 		return err
 	}
 
 	// hack to better print a single resource
-	var v any = list
+	var v any = types.NewList(list)
 	if len(list) == 1 {
 		v = list[0]
 	}
@@ -0,0 +1,162 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+
+	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
+	graphadapter "github.com/alexandremahdhaoui/vib/internal/adapter/graph"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+)
+
+const graphDesc = `
+	Usage:
+		vib graph [flags] [KIND NAME]
+	Description:
+		Print the dependency graph of resources, i.e. Profiles referencing
+		ExpressionSets referencing Resolvers. Namespaces are rendered as clusters
+		and references to missing resources are highlighted.
+		The graph starts from the given resource, or from all resources of the
+		namespace when none is given, or of all namespaces with "-A".
+	Args:
+		KIND: the kind of the resource. (optional)
+		NAME: name of the resource. (optional)`
+
+// NewGraph creates a new "graph" command.
+func NewGraph(apiServer types.APIServer, storage types.Storage) Command {
+	out := &graph{
+		allNamespaces: false,
+		apiServer:     apiServer,
+		apiVersion:    "",
+		format:        "",
+		fs:            flag.NewFlagSet("graph", flag.ExitOnError),
+		namespace:     "",
+		storage:       storage,
+	}
+
+	NewAPIVersionFlag(out.fs, &out.apiVersion)
+	NewNamespaceFlag(out.fs, &out.namespace)
+
+	out.fs.BoolVar(
+		&out.allNamespaces,
+		"A",
+		false,
+		"Graph the resources of all namespaces",
+	)
+
+	out.fs.StringVar(
+		&out.format,
+		"o",
+		string(graphadapter.DOTFormat),
+		`The output format must be one of [dot,mermaid,json]; default is "dot"`,
+	)
+
+	return out
+}
+
+// graph holds the dependencies and flags for the "graph" command.
+type graph struct {
+	allNamespaces bool
+	apiServer     types.APIServer
+	apiVersion    types.APIVersion
+	format        string
+	fs            *flag.FlagSet
+	namespace     string
+	storage       types.Storage
+}
+
+// Description implements the Command interface.
+func (g *graph) Description() string {
+	return graphDesc
+}
+
+// FS implements the Command interface.
+func (g *graph) FS() *flag.FlagSet {
+	return g.fs
+}
+
+// Run implements the Command interface.
+func (g *graph) Run() error {
+	if n := g.fs.NArg(); n != 0 && (n != 2 || g.allNamespaces) {
+		return flaterrors.Join(
+			errors.New("\"GRAPH\" expects either ZERO or TWO arguments"),
+			errUsage,
+		)
+	}
+
+	roots, err := g.roots()
+	if err != nil {
+		return err
+	}
+
+	out, err := graphadapter.New(g.storage, roots)
+	if err != nil {
+		return err
+	}
+
+	return graphadapter.Encode(os.Stdout, out, graphadapter.Format(g.format))
+}
+
+// roots returns the resources the graph starts from.
+func (g *graph) roots() ([]types.Resource[types.APIVersionKind], error) {
+	if g.fs.NArg() == 2 {
+		// The input apiVersion might be an empty string.
+		// This ensure the apiVersion is specified
+		res, err := g.apiServer.Get(types.NewAPIVersionKind(g.apiVersion, g.fs.Arg(0)))
+		if err != nil {
+			return nil, err
+		}
+
+		nsName := types.NamespacedName{Name: g.fs.Arg(1), Namespace: g.namespace}
+
+		res, err = g.storage.Get(types.NewAVKFromResource(res), nsName)
+		if err != nil {
+			return nil, err
+		}
+
+		return []types.Resource[types.APIVersionKind]{res}, nil
+	}
+
+	namespaces := []string{g.namespace}
+	if g.allNamespaces {
+		namespaceLister, ok := g.storage.(types.NamespaceLister)
+		if !ok {
+			return nil, flaterrors.Join(types.ErrType, errors.New("storage cannot list its namespaces"))
+		}
+
+		var err error
+		if namespaces, err = namespaceLister.Namespaces(); err != nil {
+			return nil, err
+		}
+	}
+
+	out := make([]types.Resource[types.APIVersionKind], 0)
+	for _, namespace := range namespaces {
+		for _, avk := range g.apiServer.List() {
+			list, err := g.storage.List(avk, namespace)
+			if err != nil {
+				return nil, err
+			}
+
+			out = append(out, list...)
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+)
+
+const historyDesc = `
+	Usage:
+		vib history [flags] KIND NAME
+	Description:
+		List the revisions of a resource. Revisions are recorded each time a
+		resource is updated or deleted.
+	Args:
+		KIND: the kind of the resource.
+		NAME: name of the resource.`
+
+// NewHistory creates a new "history" command.
+func NewHistory(
+	apiServer types.APIServer,
+	storage types.Storage,
+) Command {
+	out := &history{
+		apiServer:  apiServer,
+		apiVersion: "",
+		fs:         flag.NewFlagSet("history", flag.ExitOnError),
+		namespace:  "",
+		storage:    storage,
+	}
+
+	NewAPIVersionFlag(out.fs, &out.apiVersion)
+	NewNamespaceFlag(out.fs, &out.namespace)
+
+	return out
+}
+
+// history holds the dependencies and flags for the "history" command.
+type history struct {
+	apiServer  types.APIServer
+	apiVersion types.APIVersion
+	fs         *flag.FlagSet
+	namespace  string
+	storage    types.Storage
+}
+
+// Description implements the Command interface.
+func (h *history) Description() string {
+	return historyDesc
+}
+
+// FS implements the Command interface.
+func (h *history) FS() *flag.FlagSet {
+	return h.fs
+}
+
+// Run implements the Command interface.
+func (h *history) Run() error {
+	if h.fs.NArg() != 2 {
+		return flaterrors.Join(
+			errors.New("\"HISTORY\" expects TWO arguments"),
+			errUsage,
+		)
+	}
+
+	historyStorage, err := asHistoryStorage(h.storage)
+	if err != nil {
+		return err
+	}
+
+	// The input apiVersion might be an empty string.
+	// This ensure the apiVersion is specified
+	res, err := h.apiServer.Get(types.NewAPIVersionKind(h.apiVersion, h.fs.Arg(0)))
+	if err != nil {
+		return err
+	}
+
+	specificAvk := types.NewAVKFromResource(res)
+	nsName := types.NamespacedName{
+		Name:      h.fs.Arg(1),
+		Namespace: h.namespace,
+	}
+
+	revisions, err := historyStorage.History(specificAvk, nsName)
+	if err != nil {
+		return err
+	}
+
+	current, err := h.storage.Get(specificAvk, nsName)
+	if errors.Is(err, types.ErrNotFound) {
+		if len(revisions) == 0 {
+			return err
+		}
+
+		slog.Info(
+			"Resource has been deleted and can be restored with \"vib restore\"",
+			"name", nsName.Name,
+			"apiVersion", specificAvk.APIVersion(),
+			"kind", specificAvk.Kind(),
+			"namespace", nsName.Namespace,
+		)
+	} else if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "REVISION\tRESOURCE VERSION\tTIMESTAMP") //nolint: errcheck
+
+	for _, revision := range revisions {
+		fmt.Fprintf( //nolint: errcheck
+			w,
+			"%d\t%s\t%s\n",
+			revision.Revision,
+			revision.ResourceVersion,
+			revision.Timestamp.Format(time.RFC3339),
+		)
+	}
+
+	if err == nil {
+		fmt.Fprintf(w, "current\t%s\t\n", current.Metadata.ResourceVersion) //nolint: errcheck
+	}
+
+	return w.Flush()
+}
+
+// asHistoryStorage returns the storage as a types.HistoryStorage if it keeps the history of
+// resources.
+func asHistoryStorage(storage types.Storage) (types.HistoryStorage, error) {
+	historyStorage, ok := storage.(types.HistoryStorage)
+	if !ok {
+		return nil, flaterrors.Join(
+			types.ErrType,
+			errors.New("storage does not keep the history of resources"),
+		)
+	}
+
+	return historyStorage, nil
+}
+
+// getRevision returns the specified revision of a resource. If revision is 0, the latest revision
+// is returned.
+func getRevision(
+	historyStorage types.HistoryStorage,
+	avk types.APIVersionKind,
+	nsName types.NamespacedName,
+	revision int,
+) (types.Resource[types.APIVersionKind], error) {
+	if revision == 0 {
+		revisions, err := historyStorage.History(avk, nsName)
+		if err != nil {
+			return types.Resource[types.APIVersionKind]{}, err
+		}
+
+		if len(revisions) == 0 {
+			return types.Resource[types.APIVersionKind]{}, flaterrors.Join(
+				types.ErrNotFound,
+				fmt.Errorf("resource %q in namespace %q has no revision", nsName.Name, nsName.Namespace),
+			)
+		}
+
+		revision = revisions[len(revisions)-1].Revision
+	}
+
+	return historyStorage.GetRevision(avk, nsName, revision)
+}
+
+// NewToRevisionFlag defines a new "to-revision" flag.
+func NewToRevisionFlag(fs *flag.FlagSet, iVar *int) {
+	fs.IntVar(
+		iVar,
+		"to-revision",
+		0,
+		"The revision to restore. Defaults to the latest revision",
+	)
+}
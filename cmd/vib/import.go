@@ -0,0 +1,214 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+
+	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
+	bundleadapter "github.com/alexandremahdhaoui/vib/internal/adapter/bundle"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+)
+
+const importDesc = `
+	Usage:
+		vib import [flags] FILE
+	Description:
+		Import a bundle created with "vib export". The whole bundle is validated
+		before any resource is written.
+	Args:
+		FILE: the bundle to import. Users may use "-" to read from Stdin.`
+
+const (
+	// skipConflictPolicy keeps the stored resource.
+	skipConflictPolicy = "skip"
+	// overwriteConflictPolicy replaces the stored resource with the bundled one.
+	overwriteConflictPolicy = "overwrite"
+	// failConflictPolicy aborts the import.
+	failConflictPolicy = "fail"
+)
+
+var conflictPolicies = []string{skipConflictPolicy, overwriteConflictPolicy, failConflictPolicy}
+
+// NewImport creates a new "import" command.
+func NewImport(
+	decoder types.DynamicDecoder[types.APIVersionKind],
+	storage types.Storage,
+) Command {
+	out := &imp{
+		conflictPolicy: "",
+		decoder:        decoder,
+		fs:             flag.NewFlagSet("import", flag.ExitOnError),
+		storage:        storage,
+	}
+
+	out.fs.StringVar(
+		&out.conflictPolicy,
+		"on-conflict",
+		failConflictPolicy,
+		"What to do when a bundled resource already exists; must be one of [skip,overwrite,fail]",
+	)
+
+	return out
+}
+
+// imp holds the dependencies and flags for the "import" command.
+type imp struct {
+	conflictPolicy string
+	decoder        types.DynamicDecoder[types.APIVersionKind]
+	fs             *flag.FlagSet
+	storage        types.Storage
+}
+
+// Description implements the Command interface.
+func (i *imp) Description() string {
+	return importDesc
+}
+
+// FS implements the Command interface.
+func (i *imp) FS() *flag.FlagSet {
+	return i.fs
+}
+
+// Run implements the Command interface.
+func (i *imp) Run() error {
+	if i.fs.NArg() != 1 {
+		return flaterrors.Join(
+			errors.New("\"IMPORT\" expects ONE argument"),
+			errUsage,
+		)
+	}
+
+	if !slices.Contains(conflictPolicies, i.conflictPolicy) {
+		return flaterrors.Join(
+			types.ErrArgs,
+			fmt.Errorf("conflict policy must be one of %v; got %q", conflictPolicies, i.conflictPolicy),
+		)
+	}
+
+	filePath := i.fs.Arg(0)
+	if filePath == "-" {
+		filePath = os.Stdin.Name()
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint: errcheck
+
+	// -- 1. Read and validate the bundle.
+	_, resources, err := bundleadapter.Read(f, i.decoder)
+	if err != nil {
+		return err
+	}
+
+	// -- 2. Check references.
+	if err := types.ValidateReferences(i.storage, resources); err != nil {
+		return err
+	}
+
+	// -- 3. Resolve conflicts.
+	type action struct {
+		res  types.Resource[types.APIVersionKind]
+		verb string
+	}
+
+	var conflicts error
+	actions := make([]action, 0, len(resources))
+	for _, res := range resources {
+		stored, err := i.storage.Get(res.Spec, types.NewNamespacedNameFromMetadata(res.Metadata))
+		if errors.Is(err, types.ErrNotFound) {
+			actions = append(actions, action{res: res, verb: "created"})
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		equal, err := specEqual(stored, res)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case equal:
+			actions = append(actions, action{res: res, verb: "unchanged"})
+		case i.conflictPolicy == skipConflictPolicy:
+			actions = append(actions, action{res: res, verb: "skipped"})
+		case i.conflictPolicy == overwriteConflictPolicy:
+			actions = append(actions, action{res: res, verb: "updated"})
+		default:
+			conflicts = flaterrors.Join(conflicts, fmt.Errorf(
+				"resource %q of kind %q in namespace %q already exists",
+				res.Metadata.Name,
+				res.Kind,
+				res.Metadata.Namespace,
+			))
+		}
+	}
+
+	if conflicts != nil {
+		return flaterrors.Join(
+			conflicts,
+			types.ErrExists,
+			errors.New(`use "-on-conflict=skip" or "-on-conflict=overwrite" to import this bundle`),
+		)
+	}
+
+	// -- 4. Apply.
+	for _, a := range actions {
+		switch a.verb {
+		case "created":
+			err = i.storage.Create(a.res)
+		case "updated":
+			err = i.storage.Update(a.res)
+		}
+		if err != nil {
+			return err
+		}
+
+		slog.Info(
+			fmt.Sprintf("Successfully imported resource (%s)", a.verb),
+			"name", a.res.Metadata.Name,
+			"apiVersion", a.res.APIVersion,
+			"kind", a.res.Kind,
+			"namespace", a.res.Metadata.Namespace,
+		)
+	}
+
+	return nil
+}
+
+// specEqual returns true if both resources have the same spec.
+func specEqual(a, b types.Resource[types.APIVersionKind]) (bool, error) {
+	bA, err := json.Marshal(a.Spec)
+	if err != nil {
+		return false, err
+	}
+
+	bB, err := json.Marshal(b.Spec)
+	if err != nil {
+		return false, err
+	}
+
+	return string(bA) == string(bB), nil
+}
@@ -0,0 +1,244 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
+	shelladapter "github.com/alexandremahdhaoui/vib/internal/adapter/shell"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+	"github.com/alexandremahdhaoui/vib/pkg/apis/v1alpha1"
+)
+
+const importShellDesc = `
+	Usage:
+		vib import-shell [flags] FILE
+	Description:
+		Convert a shell rc file (e.g. ~/.bashrc) into ExpressionSets and a
+		Profile referencing them in the original order.
+		Aliases, variables and functions use the matching "vib-system"
+		resolvers. Anything else is kept as-is in "plain" ExpressionSets.
+	Args:
+		FILE: the shell rc file to import.`
+
+// NewImportShell creates a new "import-shell" command.
+func NewImportShell(apiServer types.APIServer, storage types.Storage) Command {
+	out := &importShell{
+		apiServer: apiServer,
+		dryRun:    false,
+		fs:        flag.NewFlagSet("import-shell", flag.ExitOnError),
+		name:      "",
+		namespace: "",
+		storage:   storage,
+	}
+
+	NewNamespaceFlag(out.fs, &out.namespace)
+
+	out.fs.StringVar(
+		&out.name,
+		"name",
+		"",
+		"The name of the generated Profile, also used to prefix ExpressionSets. Defaults to the file name",
+	)
+
+	out.fs.BoolVar(
+		&out.dryRun,
+		"dry-run",
+		false,
+		"Print the generated resources instead of storing them",
+	)
+
+	return out
+}
+
+// importShell holds the dependencies and flags for the "import-shell" command.
+type importShell struct {
+	apiServer types.APIServer
+	dryRun    bool
+	fs        *flag.FlagSet
+	name      string
+	namespace string
+	storage   types.Storage
+}
+
+// Description implements the Command interface.
+func (i *importShell) Description() string {
+	return importShellDesc
+}
+
+// FS implements the Command interface.
+func (i *importShell) FS() *flag.FlagSet {
+	return i.fs
+}
+
+// Run implements the Command interface.
+func (i *importShell) Run() error {
+	if i.fs.NArg() != 1 {
+		return flaterrors.Join(
+			errors.New("\"IMPORT-SHELL\" expects ONE argument"),
+			errUsage,
+		)
+	}
+
+	filePath := i.fs.Arg(0)
+
+	name := i.name
+	if name == "" {
+		name = nameFromFilename(filePath)
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint: errcheck
+
+	statements, err := shelladapter.Parse(f)
+	if err != nil {
+		return err
+	}
+
+	resources := resourcesFromStatements(name, i.namespace, statements)
+	for _, res := range resources {
+		if err := i.apiServer.Validate(res); err != nil {
+			return err
+		}
+	}
+
+	if i.dryRun {
+		outputCodec, err := NewCodec(defaultOutputEncoding)
+		if err != nil {
+			return err
+		}
+
+		for _, res := range resources {
+			b, err := outputCodec.Marshal(res)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("---\n%s", b)
+		}
+
+		return nil
+	}
+
+	for _, res := range resources {
+		verb := "created"
+		err := i.storage.Create(res)
+		if errors.Is(err, types.ErrExists) {
+			err = i.storage.Update(res)
+			verb = "updated"
+		}
+		if err != nil {
+			return err
+		}
+
+		slog.Info(
+			fmt.Sprintf("Successfully %s resource", verb),
+			"name", res.Metadata.Name,
+			"apiVersion", res.APIVersion,
+			"kind", res.Kind,
+			"namespace", res.Metadata.Namespace,
+		)
+	}
+
+	return nil
+}
+
+// resolverRefByStatementKind maps the kind of a shell statement to the "vib-system" resolver
+// rendering it.
+var resolverRefByStatementKind = map[shelladapter.StatementKind]string{
+	shelladapter.AliasStatement:      v1alpha1.AliasResolverRef,
+	shelladapter.ExportStatement:     v1alpha1.ExportedEnvironmentResolverRef,
+	shelladapter.AssignmentStatement: v1alpha1.EnvironmentResolverRef,
+	shelladapter.FunctionStatement:   v1alpha1.FunctionResolverRef,
+	shelladapter.PlainStatement:      v1alpha1.PlainResolverRef,
+}
+
+// resourcesFromStatements groups consecutive statements of the same kind into ExpressionSets, and
+// returns them followed by a Profile referencing them in order.
+func resourcesFromStatements(
+	name, namespace string,
+	statements []shelladapter.Statement,
+) []types.Resource[types.APIVersionKind] {
+	specs := make([]*v1alpha1.ExpressionSetSpec, 0)
+	var lastKind shelladapter.StatementKind
+	for _, stmt := range statements {
+		if len(specs) == 0 || stmt.Kind != lastKind {
+			specs = append(specs, &v1alpha1.ExpressionSetSpec{
+				ResolverRef: types.NamespacedName{
+					Name:      resolverRefByStatementKind[stmt.Kind],
+					Namespace: types.VibSystemNamespace,
+				},
+			})
+			lastKind = stmt.Kind
+		}
+
+		spec := specs[len(specs)-1]
+		if stmt.Kind == shelladapter.PlainStatement {
+			spec.ArbitraryKeys = append(spec.ArbitraryKeys, stmt.Raw)
+			continue
+		}
+
+		spec.KeyValues = append(spec.KeyValues, map[string]string{stmt.Key: stmt.Value})
+	}
+
+	width := max(2, len(strconv.Itoa(len(specs)-1)))
+	profile := &v1alpha1.ProfileSpec{Refs: make([]types.NamespacedName, 0, len(specs))}
+	out := make([]types.Resource[types.APIVersionKind], 0, len(specs)+1)
+	for i, spec := range specs {
+		esName := fmt.Sprintf("%s-%0*d-%s", name, width, i, spec.ResolverRef.Name)
+		out = append(out, types.Resource[types.APIVersionKind]{
+			APIVersion: v1alpha1.APIVersion,
+			Kind:       v1alpha1.ExpressionSetKind,
+			Metadata:   types.Metadata{Name: esName, Namespace: namespace},
+			Spec:       spec,
+		})
+
+		profile.Refs = append(profile.Refs, types.NamespacedName{Name: esName, Namespace: namespace})
+	}
+
+	return append(out, types.Resource[types.APIVersionKind]{
+		APIVersion: v1alpha1.APIVersion,
+		Kind:       v1alpha1.ProfileKind,
+		Metadata:   types.Metadata{Name: name, Namespace: namespace},
+		Spec:       profile,
+	})
+}
+
+var invalidNameCharsRegex = regexp.MustCompile(`[^a-z0-9]+`)
+
+// nameFromFilename computes a valid resource name from a file name, e.g. "~/.bashrc" => "bashrc".
+func nameFromFilename(path string) string {
+	name := strings.ToLower(filepath.Base(path))
+	name = strings.Trim(invalidNameCharsRegex.ReplaceAllString(name, "-"), "-")
+
+	if types.ValidateName(name) != nil {
+		return "shell"
+	}
+
+	return name
+}
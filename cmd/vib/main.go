@@ -19,7 +19,6 @@ import (
 	"errors"
 	"flag"
 	"fmt"
-	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -31,17 +30,6 @@ import (
 	"github.com/alexandremahdhaoui/vib/pkg/apis/v1alpha1"
 )
 
-// // ConfigSpec stores important information to run the vib command line.
-// // The config is always stored on disk, thus the Operator for managing Config will always be of type
-// // vib.FilesystemOperator.
-// type ConfigSpec struct {
-// 	// StorageStrategy defines which storage strategy must be used (only filesystem is supported).
-// 	StorageStrategy storageadapter.StorageStrategy
-// 	// ResourceDir specifies the absolute path to Resource definitions.
-// 	// Defaults to CONFIG_DIR/vib/resources
-// 	ResourceDir string
-// }
-
 const (
 	defaultStorageEncoding = types.YAMLEncoding
 	defaultOutputEncoding  = types.YAMLEncoding
@@ -58,6 +46,25 @@ type Command interface {
 }
 
 func main() {
+	// --------------------
+	// - GLOBAL FLAGS
+	// --------------------
+
+	global := &globalFlags{}
+	globalFS := newGlobalFlagSet(global)
+
+	args, err := parseGlobalFlags(globalFS, os.Args[1:])
+	if err != nil {
+		slog.Error(err.Error())
+		os.Exit(exitUsage)
+	}
+
+	setCompletedGlobalFlags(global, args)
+
+	if global.verbose {
+		slog.SetLogLoggerLevel(slog.LevelDebug)
+	}
+
 	// --------------------
 	// - INIT
 	// --------------------
@@ -69,32 +76,74 @@ func main() {
 	// -- dynamic resource decoder
 	drd := codecadapter.NewDynamicResourceDecoder(apiServer)
 
-	// -- storage encoding
-	storageCodec, err := NewCodec(defaultStorageEncoding)
+	// -- vib config dir
+	vibConfigDir := global.configDir
+	if vibConfigDir == "" {
+		userConfigDir, err := os.UserConfigDir()
+		if err != nil {
+			logErrAndExit(err)
+			return
+		}
+		vibConfigDir = filepath.Join(userConfigDir, "vib")
+	}
+
+	// -- vib config
+	config, err := LoadConfig(vibConfigDir)
 	if err != nil {
 		logErrAndExit(err)
 		return
 	}
 
-	// -- vib config dir
-	userConfigDir, err := os.UserConfigDir()
+	if global.storageEncoding != "" {
+		config.StorageEncoding = types.Encoding(global.storageEncoding)
+	}
+
+	slog.Debug(
+		"Loaded config",
+		"configDir", vibConfigDir,
+		"storageEncoding", config.StorageEncoding,
+		"historyLimit", *config.HistoryLimit,
+		"admissionHooks", len(config.AdmissionHooks),
+	)
+
+	// -- admission hooks
+	admissionHooks, err := NewAdmissionHooks(config, drd)
+	if err != nil {
+		logErrAndExit(err)
+		return
+	}
+
+	apiServer.RegisterAdmissionHooks(admissionHooks)
+
+	// -- storage encoding
+	storageCodec, err := NewCodec(config.StorageEncoding)
 	if err != nil {
 		logErrAndExit(err)
 		return
 	}
-	vibConfigDir := filepath.Join(userConfigDir, "vib")
 
 	// -- storage
 	storage, err := storageadapter.NewFilesystem(
 		apiServer,
 		storageCodec,
+		drd,
 		vibConfigDir,
+		*config.HistoryLimit,
 	)
 	if err != nil {
 		logErrAndExit(err)
 		return
 	}
 
+	// -- kinds defined by ResourceDefinitions. Conflicting kinds are skipped, so that their
+	// ResourceDefinitions can still be fixed or deleted.
+	if err := v1alpha1.RegisterResourceDefinitions(apiServer, storage); errors.Is(err, types.ErrExists) {
+		slog.Warn(err.Error())
+	} else if err != nil {
+		logErrAndExit(err)
+		return
+	}
+
 	// --------------------
 	// - INIT VIB SYSTEM
 	// --------------------
@@ -108,88 +157,41 @@ func main() {
 	// --------------------
 
 	cmds := []Command{
-		NewApply(drd, storage), // Read, UpdateOrCreate
+		NewAPIResources(apiServer),
+		NewApply(apiServer, drd, storage), // Read, UpdateOrCreate
+		NewCompletion(),
+		NewConvert(apiServer, drd),
 		NewCreate(apiServer, storage),
 		NewDelete(apiServer, storage),
-		NewEdit(apiServer, storage), // List, EditText, UpdateOrCreate
+		NewDescribe(apiServer, storage),
+		NewEdit(apiServer, drd, storage), // List, EditText, UpdateOrCreate
+		NewExport(apiServer, storage),
+		NewExplain(apiServer),
 		NewGet(apiServer, storage),
+		NewGraph(apiServer, storage),
 		// NewGrep(TODO), // List, regexp.Match, Print
+		NewHistory(apiServer, storage),
+		NewImport(drd, storage),
+		NewImportShell(apiServer, storage),
+		NewMigrateStorage(apiServer, drd, vibConfigDir, config),
 		NewRender(apiServer, storage),
+		NewRestore(apiServer, storage),
+		NewRollback(apiServer, storage),
+		NewSchema(apiServer),
 	}
 
-	if len(os.Args) < 2 {
-		help(os.Stderr, cmds)
-		os.Exit(1)
-	}
+	cmds = append(cmds, NewComplete(apiServer, storage, cmds))
 
 	// --------------------
 	// - RUN CMDS
 	// --------------------
 
-	found := false
-	for _, cmd := range cmds {
-		if os.Args[1] != cmd.FS().Name() {
-			continue
-		}
-
-		found = true
-		if len(os.Args) > 1 {
-			if err := cmd.FS().Parse(os.Args[2:]); err != nil {
-				help(os.Stderr, cmds) // actually not called
-				os.Exit(1)            // not called
-			}
-		}
-
-		if err := cmd.Run(); err != nil {
-			logErrAndExit(err)
-			return
-		}
-
-		break
-	}
-
-	if !found {
-		help(os.Stderr, cmds)
-		os.Exit(1)
-	}
+	os.Exit(newCLI(cmds, globalFS).run(args))
 }
 
 func logErrAndExit(err error) {
 	slog.Error(err.Error())
-	os.Exit(1)
-}
-
-const usageFmt = `USAGE: %s [command]
-
-Available Commands:
-
-`
-
-func help(w io.Writer, cmds []Command) {
-	fmt.Fprintf(w, usageFmt, os.Args[0]) //nolint: errcheck
-
-	for _, cmd := range cmds {
-		fmt.Fprintf(w, "%s\n", cmd.FS().Name()) //nolint: errcheck
-
-		// TODO: ensure that description does not exceed (80-indent) charachters per line
-		indent := "\t"
-		fmt.Fprintf(w, "%s%s\n", indent, cmd.Description()) //nolint: errcheck
-		fmt.Fprintf(w, "%sFlags:\n", indent)                //nolint: errcheck
-
-		indent = "\t\t"
-		cmd.FS().VisitAll(func(fl *flag.Flag) {
-			var longFlag string
-			if len(fl.Name) > 1 {
-				longFlag = "-"
-			}
-
-			fmt.Fprintf(w, "%s-%s%s\t%s\n", indent, longFlag, fl.Name, fl.Usage) //nolint: errcheck
-		})
-
-		fmt.Fprintf(w, "\n") //nolint: errcheck
-	}
-
-	fmt.Fprintf(w, "\n") //nolint: errcheck
+	os.Exit(exitError)
 }
 
 // ---------------------------------------------------------------------
@@ -282,6 +284,18 @@ func initVibSystemNamespace(storage types.Storage) error {
 		}
 
 		fixedResolver.Metadata.Namespace = types.VibSystemNamespace
+
+		// -- existing resolvers are skipped, so that they are not admitted on every run.
+		_, err := storage.Get(
+			types.NewAVKFromResource(fixedResolver),
+			types.NewNamespacedNameFromMetadata(fixedResolver.Metadata),
+		)
+		if err == nil {
+			continue
+		} else if !errors.Is(err, types.ErrNotFound) {
+			return err
+		}
+
 		if err := storage.Create(fixedResolver); err != nil && !errors.Is(err, types.ErrExists) {
 			return err
 		}
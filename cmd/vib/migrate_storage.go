@@ -0,0 +1,162 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+
+	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
+	storageadapter "github.com/alexandremahdhaoui/vib/internal/adapter/storage"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+)
+
+const migrateStorageDesc = `
+	Usage:
+		vib migrate-storage [flags]
+	Description:
+		Rewrite every stored resource with the specified encoding, and set the
+		"storageEncoding" of the config. Files are rewritten atomically and the
+		resources are not modified.
+		Resources can be read with any supported encoding: the storage remains
+		usable if the migration is interrupted.`
+
+// NewMigrateStorage creates a new "migrate-storage" command.
+func NewMigrateStorage(
+	apiServer types.APIServer,
+	decoder types.DynamicDecoder[types.APIVersionKind],
+	vibConfigDir string,
+	config ConfigSpec,
+) Command {
+	out := &migrateStorage{
+		apiServer:    apiServer,
+		config:       config,
+		decoder:      decoder,
+		fs:           flag.NewFlagSet("migrate-storage", flag.ExitOnError),
+		to:           "",
+		vibConfigDir: vibConfigDir,
+	}
+
+	out.fs.StringVar(
+		&out.to,
+		"to",
+		"",
+		"The encoding to migrate to; must be one of [json,jsonc,toml,yaml]",
+	)
+
+	return out
+}
+
+// migrateStorage holds the dependencies and flags for the "migrate-storage" command.
+type migrateStorage struct {
+	apiServer    types.APIServer
+	config       ConfigSpec
+	decoder      types.DynamicDecoder[types.APIVersionKind]
+	fs           *flag.FlagSet
+	to           string
+	vibConfigDir string
+}
+
+// Description implements the Command interface.
+func (m *migrateStorage) Description() string {
+	return migrateStorageDesc
+}
+
+// FS implements the Command interface.
+func (m *migrateStorage) FS() *flag.FlagSet {
+	return m.fs
+}
+
+// Run implements the Command interface.
+func (m *migrateStorage) Run() error {
+	if m.fs.NArg() != 0 {
+		return flaterrors.Join(
+			errors.New("\"MIGRATE-STORAGE\" does not expect arguments"),
+			errUsage,
+		)
+	}
+
+	if m.to == "" {
+		return errors.New(`a valid encoding must be provided using the "-to" flag`)
+	}
+
+	codec, err := NewCodec(types.Encoding(m.to))
+	if err != nil {
+		return err
+	}
+
+	storage, err := storageadapter.NewFilesystem(
+		m.apiServer,
+		codec,
+		m.decoder,
+		m.vibConfigDir,
+		*m.config.HistoryLimit,
+	)
+	if err != nil {
+		return err
+	}
+
+	migrator, ok := storage.(types.EncodingMigrator)
+	if !ok {
+		return flaterrors.Join(types.ErrType, errors.New("storage does not support encoding migration"))
+	}
+
+	// -- resources migrated before an error are reported.
+	migrations, err := migrator.MigrateEncoding()
+	printMigrations(migrations)
+	if err != nil {
+		return err
+	}
+
+	if err := SetConfigValue(m.vibConfigDir, "storageEncoding", m.to); err != nil {
+		return err
+	}
+
+	slog.Info(
+		"Successfully migrated storage",
+		"encoding", m.to,
+		"migrated", len(migrations),
+	)
+
+	return nil
+}
+
+// printMigrations prints the migrated resources as a table.
+func printMigrations(migrations []types.Migration) {
+	if len(migrations) == 0 {
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tFILENAME\tFROM\tTO") //nolint: errcheck
+
+	for _, migration := range migrations {
+		fmt.Fprintf( //nolint: errcheck
+			w,
+			"%s\t%s\t%s\t%s\n",
+			migration.Namespace,
+			migration.Filename,
+			migration.From,
+			migration.To,
+		)
+	}
+
+	_ = w.Flush()
+}
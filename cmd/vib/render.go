@@ -73,7 +73,7 @@ func (r *render) Run() error {
 	if r.fs.NArg() < 2 {
 		return flaterrors.Join(
 			errors.New("\"RENDER\" requires TWO arguments"),
-			errors.New(renderDesc), //nolint staticcheck
+			errUsage,
 		)
 	}
 
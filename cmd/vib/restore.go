@@ -0,0 +1,127 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"errors"
+	"flag"
+	"log/slog"
+
+	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+)
+
+const restoreDesc = `
+	Usage:
+		vib restore [flags] KIND NAME
+	Description:
+		Restore a deleted resource from its history.
+	Args:
+		KIND: the kind of the resource.
+		NAME: name of the resource to restore.`
+
+// NewRestore creates a new "restore" command.
+func NewRestore(
+	apiServer types.APIServer,
+	storage types.Storage,
+) Command {
+	out := &restore{
+		apiServer:  apiServer,
+		apiVersion: "",
+		fs:         flag.NewFlagSet("restore", flag.ExitOnError),
+		namespace:  "",
+		revision:   0,
+		storage:    storage,
+	}
+
+	NewAPIVersionFlag(out.fs, &out.apiVersion)
+	NewNamespaceFlag(out.fs, &out.namespace)
+	NewToRevisionFlag(out.fs, &out.revision)
+
+	return out
+}
+
+// restore holds the dependencies and flags for the "restore" command.
+type restore struct {
+	apiServer  types.APIServer
+	apiVersion types.APIVersion
+	fs         *flag.FlagSet
+	namespace  string
+	revision   int
+	storage    types.Storage
+}
+
+// Description implements the Command interface.
+func (r *restore) Description() string {
+	return restoreDesc
+}
+
+// FS implements the Command interface.
+func (r *restore) FS() *flag.FlagSet {
+	return r.fs
+}
+
+// Run implements the Command interface.
+func (r *restore) Run() error {
+	if r.fs.NArg() != 2 {
+		return flaterrors.Join(
+			errors.New("\"RESTORE\" expects TWO arguments"),
+			errUsage,
+		)
+	}
+
+	historyStorage, err := asHistoryStorage(r.storage)
+	if err != nil {
+		return err
+	}
+
+	// The input apiVersion might be an empty string.
+	// This ensure the apiVersion is specified
+	res, err := r.apiServer.Get(types.NewAPIVersionKind(r.apiVersion, r.fs.Arg(0)))
+	if err != nil {
+		return err
+	}
+
+	specificAvk := types.NewAVKFromResource(res)
+	nsName := types.NamespacedName{
+		Name:      r.fs.Arg(1),
+		Namespace: r.namespace,
+	}
+
+	revision, err := getRevision(historyStorage, specificAvk, nsName, r.revision)
+	if err != nil {
+		return err
+	}
+
+	if err := r.apiServer.Validate(revision); err != nil {
+		return err
+	}
+
+	// -- Create fails if the resource was not deleted.
+	if err := r.storage.Create(revision); err != nil {
+		return err
+	}
+
+	slog.Info(
+		"Successfully restored resource",
+		"name", nsName.Name,
+		"apiVersion", specificAvk.APIVersion(),
+		"kind", specificAvk.Kind(),
+		"namespace", nsName.Namespace,
+	)
+
+	return nil
+}
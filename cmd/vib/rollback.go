@@ -0,0 +1,137 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"errors"
+	"flag"
+	"log/slog"
+
+	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+)
+
+const rollbackDesc = `
+	Usage:
+		vib rollback [flags] KIND NAME
+	Description:
+		Rollback a resource to one of its revisions. The current version of the
+		resource is recorded in its history.
+	Args:
+		KIND: the kind of the resource.
+		NAME: name of the resource to rollback.`
+
+// NewRollback creates a new "rollback" command.
+func NewRollback(
+	apiServer types.APIServer,
+	storage types.Storage,
+) Command {
+	out := &rollback{
+		apiServer:  apiServer,
+		apiVersion: "",
+		fs:         flag.NewFlagSet("rollback", flag.ExitOnError),
+		namespace:  "",
+		revision:   0,
+		storage:    storage,
+	}
+
+	NewAPIVersionFlag(out.fs, &out.apiVersion)
+	NewNamespaceFlag(out.fs, &out.namespace)
+	NewToRevisionFlag(out.fs, &out.revision)
+
+	return out
+}
+
+// rollback holds the dependencies and flags for the "rollback" command.
+type rollback struct {
+	apiServer  types.APIServer
+	apiVersion types.APIVersion
+	fs         *flag.FlagSet
+	namespace  string
+	revision   int
+	storage    types.Storage
+}
+
+// Description implements the Command interface.
+func (r *rollback) Description() string {
+	return rollbackDesc
+}
+
+// FS implements the Command interface.
+func (r *rollback) FS() *flag.FlagSet {
+	return r.fs
+}
+
+// Run implements the Command interface.
+func (r *rollback) Run() error {
+	if r.fs.NArg() != 2 {
+		return flaterrors.Join(
+			errors.New("\"ROLLBACK\" expects TWO arguments"),
+			errUsage,
+		)
+	}
+
+	historyStorage, err := asHistoryStorage(r.storage)
+	if err != nil {
+		return err
+	}
+
+	// The input apiVersion might be an empty string.
+	// This ensure the apiVersion is specified
+	res, err := r.apiServer.Get(types.NewAPIVersionKind(r.apiVersion, r.fs.Arg(0)))
+	if err != nil {
+		return err
+	}
+
+	specificAvk := types.NewAVKFromResource(res)
+	nsName := types.NamespacedName{
+		Name:      r.fs.Arg(1),
+		Namespace: r.namespace,
+	}
+
+	current, err := r.storage.Get(specificAvk, nsName)
+	if errors.Is(err, types.ErrNotFound) {
+		return flaterrors.Join(err, errors.New(`deleted resources must be restored with "vib restore"`))
+	} else if err != nil {
+		return err
+	}
+
+	revision, err := getRevision(historyStorage, specificAvk, nsName, r.revision)
+	if err != nil {
+		return err
+	}
+
+	// -- Rollback is an update of the current version.
+	revision.Metadata.ResourceVersion = current.Metadata.ResourceVersion
+
+	if err := r.apiServer.Validate(revision); err != nil {
+		return err
+	}
+
+	if err := r.storage.Update(revision); err != nil {
+		return err
+	}
+
+	slog.Info(
+		"Successfully rolled back resource",
+		"name", nsName.Name,
+		"apiVersion", specificAvk.APIVersion(),
+		"kind", specificAvk.Kind(),
+		"namespace", nsName.Namespace,
+	)
+
+	return nil
+}
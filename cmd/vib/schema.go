@@ -0,0 +1,146 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+)
+
+const schemaDesc = `
+	Usage:
+		vib schema [flags] KIND
+		vib schema -all [-o DIR]
+	Description:
+		Print the JSON Schema of the resources of kind "KIND". Schemas can be
+		used by editors, e.g. with yaml-language-server, to complete and
+		validate resources.
+		If "-o" is set, schemas are written to DIR, one file per kind.
+	Args:
+		KIND: the kind of the resource.`
+
+// NewSchema creates a new "schema" command.
+func NewSchema(apiServer types.APIServer) Command {
+	out := &schema{
+		all:        false,
+		apiServer:  apiServer,
+		apiVersion: "",
+		dir:        "",
+		fs:         flag.NewFlagSet("schema", flag.ExitOnError),
+	}
+
+	NewAPIVersionFlag(out.fs, &out.apiVersion)
+
+	out.fs.BoolVar(&out.all, "all", false, "Generate the schemas of every registered kind")
+	out.fs.StringVar(&out.dir, "o", "", "The directory where schemas are written. Defaults to Stdout")
+
+	return out
+}
+
+// schema holds the dependencies and flags for the "schema" command.
+type schema struct {
+	all        bool
+	apiServer  types.APIServer
+	apiVersion types.APIVersion
+	dir        string
+	fs         *flag.FlagSet
+}
+
+// Description implements the Command interface.
+func (s *schema) Description() string {
+	return schemaDesc
+}
+
+// FS implements the Command interface.
+func (s *schema) FS() *flag.FlagSet {
+	return s.fs
+}
+
+// Run implements the Command interface.
+func (s *schema) Run() error {
+	if s.all == (s.fs.NArg() == 1) || s.fs.NArg() > 1 {
+		return flaterrors.Join(
+			errors.New("\"SCHEMA\" expects either ONE argument or the \"-all\" flag"),
+			errUsage,
+		)
+	}
+
+	avks := s.apiServer.List()
+	if !s.all {
+		res, err := s.apiServer.Get(types.NewAPIVersionKind(s.apiVersion, s.fs.Arg(0)))
+		if err != nil {
+			return err
+		}
+
+		avks = []types.APIVersionKind{types.NewAVKFromResource(res)}
+	}
+
+	if s.dir != "" {
+		if err := os.MkdirAll(s.dir, 0750); err != nil {
+			return err
+		}
+	}
+
+	for _, avk := range avks {
+		out, err := s.apiServer.Schema(avk)
+		if err != nil {
+			return err
+		}
+
+		b, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		if s.dir == "" {
+			fmt.Println(string(b))
+			continue
+		}
+
+		path := filepath.Join(s.dir, schemaFilename(avk))
+		if err := os.WriteFile(path, append(b, '\n'), 0640); err != nil {
+			return err
+		}
+
+		slog.Info(
+			"Successfully wrote schema",
+			"apiVersion", avk.APIVersion(),
+			"kind", avk.Kind(),
+			"file", path,
+		)
+	}
+
+	return nil
+}
+
+// schemaFilename returns the name of the file holding the schema of avk,
+// e.g. "vib.amahdha.com_v1alpha1.profile.json".
+func schemaFilename(avk types.APIVersionKind) string {
+	return strings.ToLower(fmt.Sprintf(
+		"%s.%s.json",
+		strings.ReplaceAll(avk.APIVersion(), "/", "_"),
+		avk.Kind(),
+	))
+}
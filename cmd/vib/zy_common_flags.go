@@ -47,6 +47,23 @@ func NewOutputEncodingFlag(fs *flag.FlagSet, sVar *string) {
 		sVar,
 		"o",
 		string(defaultOutputEncoding),
-		"The output encoding must be one of [json,yaml]; default is \"yaml\"",
+		"The output encoding must be one of [json,jsonc,toml,yaml]; default is \"yaml\"",
+	)
+}
+
+const (
+	// errorRefPolicy rejects resources referencing resources that do not exist.
+	errorRefPolicy = "error"
+	// warnRefPolicy logs a warning when resources reference resources that do not exist.
+	warnRefPolicy = "warn"
+)
+
+// NewRefPolicyFlag defines a new "ref-policy" flag.
+func NewRefPolicyFlag(fs *flag.FlagSet, sVar *string) {
+	fs.StringVar(
+		sVar,
+		"ref-policy",
+		errorRefPolicy,
+		`The policy applied when a resource references a resource that does not exist; must be one of [error,warn]`,
 	)
 }
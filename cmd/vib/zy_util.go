@@ -17,6 +17,7 @@ package main
 
 import (
 	"fmt"
+	"log/slog"
 
 	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
 	codecadapter "github.com/alexandremahdhaoui/vib/internal/adapter/codec"
@@ -30,6 +31,10 @@ func NewCodec(encoding types.Encoding) (types.Codec, error) {
 		return codecadapter.NewJSON(), nil
 	case types.YAMLEncoding:
 		return codecadapter.NewYAML(), nil
+	case types.TOMLEncoding:
+		return codecadapter.NewTOML(), nil
+	case types.JSONCEncoding:
+		return codecadapter.NewJSONC(), nil
 	default:
 		return nil, flaterrors.Join(
 			types.ErrEncoding,
@@ -37,3 +42,27 @@ func NewCodec(encoding types.Encoding) (types.Codec, error) {
 		)
 	}
 }
+
+// ValidateReferences ensures the resources referenced by resources exist, either in resources or in
+// storage. Dangling references are rejected or logged as warnings depending on refPolicy.
+func ValidateReferences(
+	storage types.Storage,
+	resources []types.Resource[types.APIVersionKind],
+	refPolicy string,
+) error {
+	if refPolicy != errorRefPolicy && refPolicy != warnRefPolicy {
+		return flaterrors.Join(
+			types.ErrVal,
+			fmt.Errorf("unrecognized ref-policy %q", refPolicy),
+		)
+	}
+
+	err := types.ValidateReferences(storage, resources)
+	if err == nil || refPolicy == errorRefPolicy {
+		return err
+	}
+
+	slog.Warn(err.Error())
+
+	return nil
+}
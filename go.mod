@@ -4,7 +4,9 @@ go 1.24
 
 require (
 	github.com/alexandremahdhaoui/tooling v0.1.4
+	github.com/pelletier/go-toml/v2 v2.4.3
 	github.com/stretchr/testify v1.9.0
+	github.com/tailscale/hujson v0.0.0-20260302212456-ecc657c15afd
 	sigs.k8s.io/yaml v1.6.0
 )
 
@@ -0,0 +1,262 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command gendocs generates the "zz_generated.docs.go" file of a Go package. The generated file
+// registers the doc comments of the exported types of the package, and of their fields, using
+// types.RegisterDocs.
+//
+// It is meant to be run with "go generate" from the directory of the package.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	outputFilename     = "zz_generated.docs.go"
+	boilerplatePath    = "hack/boilerplate.go.txt"
+	typesPkgPath       = "internal/types"
+	generatedHeaderFmt = "// Code generated by gendocs. DO NOT EDIT.\n\npackage %s\n\n"
+)
+
+// ignoredPrefixes are the prefixes of the paragraphs of doc comments that are not documentation.
+var ignoredPrefixes = []string{"TODO", "WARN", "FIXME", "INFO"}
+
+func main() {
+	if err := run(); err != nil {
+		slog.Error(err.Error())
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	moduleDir, modulePath, err := findModule(dir)
+	if err != nil {
+		return err
+	}
+
+	rel, err := filepath.Rel(moduleDir, dir)
+	if err != nil {
+		return err
+	}
+
+	pkgPath := modulePath + "/" + filepath.ToSlash(rel)
+
+	pkgName, typeDocs, err := parseDocs(dir)
+	if err != nil {
+		return err
+	}
+
+	boilerplate, err := os.ReadFile(filepath.Join(moduleDir, boilerplatePath))
+	if err != nil {
+		return err
+	}
+
+	b, err := generate(boilerplate, pkgName, pkgPath, modulePath+"/"+typesPkgPath, typeDocs)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, outputFilename), b, 0644)
+}
+
+// findModule returns the directory and the path of the Go module containing dir.
+func findModule(dir string) (string, string, error) {
+	for {
+		b, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			for _, line := range strings.Split(string(b), "\n") {
+				if modulePath, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+					return dir, strings.Trim(strings.TrimSpace(modulePath), `"`), nil
+				}
+			}
+
+			return "", "", fmt.Errorf("cannot find module path in %q", filepath.Join(dir, "go.mod"))
+		} else if !os.IsNotExist(err) {
+			return "", "", err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", errors.New("cannot find go.mod")
+		}
+
+		dir = parent
+	}
+}
+
+// parseDocs returns the name of the package in dir and the doc comments of its exported types and
+// of their exported fields.
+func parseDocs(dir string) (string, map[string]string, error) {
+	filenames, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return "", nil, err
+	}
+
+	var pkgName string
+	typeDocs := make(map[string]string)
+	fset := token.NewFileSet()
+
+	for _, filename := range filenames {
+		base := filepath.Base(filename)
+		if strings.HasSuffix(base, "_test.go") || base == outputFilename {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
+		if err != nil {
+			return "", nil, err
+		}
+
+		pkgName = f.Name.Name
+
+		for _, decl := range f.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range genDecl.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				if !typeSpec.Name.IsExported() {
+					continue
+				}
+
+				doc := typeSpec.Doc
+				if doc == nil && len(genDecl.Specs) == 1 {
+					doc = genDecl.Doc
+				}
+
+				addDoc(typeDocs, typeSpec.Name.Name, doc)
+
+				structType, ok := typeSpec.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+
+				for _, field := range structType.Fields.List {
+					for _, name := range field.Names {
+						if !name.IsExported() {
+							continue
+						}
+
+						fieldDoc := field.Doc
+						if fieldDoc == nil {
+							fieldDoc = field.Comment
+						}
+
+						addDoc(typeDocs, typeSpec.Name.Name+"."+name.Name, fieldDoc)
+					}
+				}
+			}
+		}
+	}
+
+	if pkgName == "" {
+		return "", nil, fmt.Errorf("cannot find go files in %q", dir)
+	}
+
+	return pkgName, typeDocs, nil
+}
+
+// addDoc adds the text of comment to typeDocs, if any.
+func addDoc(typeDocs map[string]string, key string, comment *ast.CommentGroup) {
+	if comment == nil {
+		return
+	}
+
+	if text := cleanDoc(comment.Text()); text != "" {
+		typeDocs[key] = text
+	}
+}
+
+// cleanDoc joins the lines of each paragraph of a doc comment, and removes the paragraphs that are
+// not documentation, e.g. "TODO: ...". Lines following such a prefix are removed until the end of
+// the paragraph.
+func cleanDoc(text string) string {
+	paragraphs := make([]string, 0)
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		lines := make([]string, 0)
+		for _, line := range strings.Split(paragraph, "\n") {
+			line = strings.TrimSpace(line)
+			if slices.ContainsFunc(ignoredPrefixes, func(prefix string) bool {
+				return strings.HasPrefix(line, prefix+":")
+			}) {
+				break
+			}
+
+			if line != "" {
+				lines = append(lines, line)
+			}
+		}
+
+		if len(lines) > 0 {
+			paragraphs = append(paragraphs, strings.Join(lines, " "))
+		}
+	}
+
+	return strings.Join(paragraphs, "\n")
+}
+
+// generate returns the formatted content of the generated file.
+func generate(
+	boilerplate []byte,
+	pkgName, pkgPath, typesPkgPath string,
+	typeDocs map[string]string,
+) ([]byte, error) {
+	buf := bytes.NewBuffer(bytes.TrimSpace(boilerplate))
+	buf.WriteString("\n\n")
+	fmt.Fprintf(buf, generatedHeaderFmt, pkgName)
+
+	registerDocs := "RegisterDocs"
+	if pkgPath != typesPkgPath {
+		fmt.Fprintf(buf, "import %q\n\n", typesPkgPath)
+		registerDocs = "types.RegisterDocs"
+	}
+
+	keys := make([]string, 0, len(typeDocs))
+	for key := range typeDocs {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	fmt.Fprintf(buf, "func init() {\n%s(%q, map[string]string{\n", registerDocs, pkgPath)
+
+	for _, key := range keys {
+		fmt.Fprintf(buf, "%q: %q,\n", key, typeDocs[key])
+	}
+
+	buf.WriteString("})\n}\n")
+
+	return format.Source(buf.Bytes())
+}
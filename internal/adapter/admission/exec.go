@@ -0,0 +1,248 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admissionadapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+)
+
+const (
+	// DefaultTimeout is the timeout of admission hooks that do not specify one.
+	DefaultTimeout = 10 * time.Second
+
+	// waitDelay bounds the time spent waiting for the children of a timed out command to close
+	// its output.
+	waitDelay = time.Second
+)
+
+// Config configures an exec admission hook.
+type Config struct {
+	// Name is the name of the hook, used to report errors.
+	Name string `json:"name"`
+	// Command is the executable run to review operations. It receives an AdmissionReview holding
+	// the request on stdin, and must write an AdmissionReview holding the response on stdout.
+	Command string `json:"command"`
+	// Args is a list of arguments passed to the command.
+	Args []string `json:"args,omitempty"`
+	// Timeout is the maximum duration of the command, e.g. "5s". Defaults to "10s".
+	Timeout string `json:"timeout,omitempty"`
+	// Operations are the reviewed operations, among Create, Update and Delete. Defaults to all.
+	Operations []types.Operation `json:"operations,omitempty"`
+	// Kinds are the kinds of the reviewed resources. Defaults to all.
+	Kinds []types.Kind `json:"kinds,omitempty"`
+}
+
+// NewExec returns an admission hook running an executable. Patched resources are decoded with
+// decoder.
+func NewExec(
+	config Config,
+	decoder types.DynamicDecoder[types.APIVersionKind],
+) (types.AdmissionHook, error) {
+	if config.Name == "" || config.Command == "" {
+		return nil, flaterrors.Join(
+			types.ErrVal,
+			errors.New("admission hooks must specify a name and a command"),
+		)
+	}
+
+	timeout := DefaultTimeout
+	if config.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(config.Timeout); err != nil {
+			return nil, flaterrors.Join(
+				err,
+				types.ErrVal,
+				fmt.Errorf("invalid timeout of admission hook %q", config.Name),
+			)
+		}
+	}
+
+	for _, op := range config.Operations {
+		if !slices.Contains(types.Operations, op) {
+			return nil, flaterrors.Join(
+				types.ErrVal,
+				fmt.Errorf("invalid operation %q of admission hook %q", op, config.Name),
+			)
+		}
+	}
+
+	return &execHook{
+		config:  config,
+		decoder: decoder,
+		timeout: timeout,
+	}, nil
+}
+
+// execHook implements the types.AdmissionHook interface.
+type execHook struct {
+	config  Config
+	decoder types.DynamicDecoder[types.APIVersionKind]
+	timeout time.Duration
+}
+
+// Name implements the types.AdmissionHook interface.
+func (h *execHook) Name() string {
+	return h.config.Name
+}
+
+// Review implements the types.AdmissionHook interface.
+// Messages of allowed operations are logged as warnings.
+func (h *execHook) Review(
+	op types.Operation,
+	res types.Resource[types.APIVersionKind],
+) (types.Resource[types.APIVersionKind], error) {
+	if !h.matches(op, res.Kind) {
+		return res, nil
+	}
+
+	response, err := h.run(types.NewAdmissionReview(op, res))
+	if err != nil {
+		return types.Resource[types.APIVersionKind]{}, flaterrors.Join(
+			err,
+			fmt.Errorf("running admission hook %q", h.config.Name),
+		)
+	}
+
+	if !response.Allowed {
+		errs := types.ErrDenied
+		for _, msg := range response.Messages {
+			errs = flaterrors.Join(errs, errors.New(msg))
+		}
+
+		return types.Resource[types.APIVersionKind]{}, flaterrors.Join(
+			errs,
+			fmt.Errorf("%s of %s denied by admission hook %q",
+				op,
+				types.NewReferenceFromResource(res),
+				h.config.Name,
+			),
+		)
+	}
+
+	for _, msg := range response.Messages {
+		slog.Warn(msg, "hook", h.config.Name, "operation", op, "kind", res.Kind, "name", res.Metadata.Name)
+	}
+
+	if len(response.Patch) == 0 || op == types.DeleteOperation {
+		return res, nil
+	}
+
+	out, err := h.patch(res, response.Patch)
+	if err != nil {
+		return types.Resource[types.APIVersionKind]{}, flaterrors.Join(
+			err,
+			fmt.Errorf("applying the patch of admission hook %q", h.config.Name),
+		)
+	}
+
+	return out, nil
+}
+
+// matches returns true if the hook reviews op performed on resources of the given kind.
+func (h *execHook) matches(op types.Operation, kind types.Kind) bool {
+	if len(h.config.Operations) > 0 && !slices.Contains(h.config.Operations, op) {
+		return false
+	}
+
+	if len(h.config.Kinds) > 0 && !slices.ContainsFunc(h.config.Kinds, func(k types.Kind) bool {
+		return strings.EqualFold(k, kind)
+	}) {
+		return false
+	}
+
+	return true
+}
+
+// run runs the command of the hook and returns its response.
+func (h *execHook) run(review types.AdmissionReview) (*types.AdmissionResponse, error) {
+	b, err := json.Marshal(review)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	stderr := new(bytes.Buffer)
+	cmd := exec.CommandContext(ctx, h.config.Command, h.config.Args...)
+	cmd.Stdin = bytes.NewReader(b)
+	cmd.Stderr = stderr
+	cmd.WaitDelay = waitDelay
+
+	stdout, err := cmd.Output()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("timed out after %s", h.timeout)
+	} else if err != nil {
+		return nil, flaterrors.Join(err, errors.New(strings.TrimSpace(stderr.String())))
+	}
+
+	out := types.AdmissionReview{}
+	if err := json.Unmarshal(stdout, &out); err != nil {
+		return nil, flaterrors.Join(err, types.ErrVal, errors.New("invalid AdmissionReview"))
+	}
+
+	if out.Response == nil {
+		return nil, flaterrors.Join(types.ErrVal, errors.New("AdmissionReview has no response"))
+	}
+
+	return out.Response, nil
+}
+
+// patch applies a JSON Patch to res and decodes the patched resource.
+func (h *execHook) patch(
+	res types.Resource[types.APIVersionKind],
+	patch []types.PatchOperation,
+) (types.Resource[types.APIVersionKind], error) {
+	b, err := json.Marshal(res)
+	if err != nil {
+		return types.Resource[types.APIVersionKind]{}, err
+	}
+
+	if b, err = applyPatch(b, patch); err != nil {
+		return types.Resource[types.APIVersionKind]{}, err
+	}
+
+	list, err := h.decoder.Decode(bytes.NewReader(b))
+	if err != nil {
+		return types.Resource[types.APIVersionKind]{}, err
+	}
+
+	if len(list) != 1 {
+		return types.Resource[types.APIVersionKind]{}, flaterrors.Join(
+			types.ErrVal,
+			errors.New("patched document must contain exactly one resource"),
+		)
+	}
+
+	// -- preserve comments and formatting of the original document.
+	out := list[0]
+	out.SetNode(res.Node())
+
+	return out, nil
+}
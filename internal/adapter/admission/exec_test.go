@@ -0,0 +1,99 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admissionadapter_test
+
+import (
+	"testing"
+
+	admissionadapter "github.com/alexandremahdhaoui/vib/internal/adapter/admission"
+	codecadapter "github.com/alexandremahdhaoui/vib/internal/adapter/codec"
+	"github.com/alexandremahdhaoui/vib/internal/service"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+	"github.com/alexandremahdhaoui/vib/pkg/apis/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExec(t *testing.T) {
+	apiServer := service.NewAPIServer()
+	v1alpha1.RegisterWithManager(apiServer)
+
+	drd := codecadapter.NewDynamicResourceDecoder(apiServer)
+
+	res := types.Resource[types.APIVersionKind]{
+		APIVersion: v1alpha1.APIVersion,
+		Kind:       v1alpha1.ExpressionSetKind,
+		Metadata:   types.Metadata{Name: "es", Namespace: "team-a"},
+		Spec: &v1alpha1.ExpressionSetSpec{
+			KeyValues: []map[string]string{{"AWS_PROFILE": "prod"}},
+		},
+	}
+
+	newHook := func(t *testing.T, script string, config admissionadapter.Config) types.AdmissionHook {
+		t.Helper()
+
+		config.Name = "test"
+		config.Command = "sh"
+		config.Args = []string{"-c", script}
+
+		hook, err := admissionadapter.NewExec(config, drd)
+		assert.NoError(t, err)
+
+		return hook
+	}
+
+	t.Run("Deny", func(t *testing.T) {
+		hook := newHook(t, `cat >/dev/null; echo '{"response":{"allowed":false,"messages":["no AWS_* in team-a"]}}'`,
+			admissionadapter.Config{})
+
+		_, err := hook.Review(types.CreateOperation, res)
+		assert.ErrorIs(t, err, types.ErrDenied)
+		assert.ErrorContains(t, err, "no AWS_* in team-a")
+	})
+
+	t.Run("Patch", func(t *testing.T) {
+		hook := newHook(t, `cat >/dev/null; echo '{"response":{"allowed":true,"patch":[
+			{"op":"add","path":"/spec/keyValues/-","value":{"EDITOR":"vim"}},
+			{"op":"replace","path":"/spec/keyValues/0/AWS_PROFILE","value":"dev"},
+			{"op":"add","path":"/metadata/labels","value":{"team":"a"}}
+		]}}'`, admissionadapter.Config{})
+
+		out, err := hook.Review(types.UpdateOperation, res)
+		assert.NoError(t, err)
+		assert.Equal(t,
+			[]map[string]string{{"AWS_PROFILE": "dev"}, {"EDITOR": "vim"}},
+			out.Spec.(*v1alpha1.ExpressionSetSpec).KeyValues,
+		)
+		assert.Equal(t, map[string]string{"team": "a"}, out.Metadata.Labels)
+	})
+
+	t.Run("Filtered", func(t *testing.T) {
+		hook := newHook(t, `exit 1`, admissionadapter.Config{
+			Operations: []types.Operation{types.DeleteOperation},
+			Kinds:      []types.Kind{v1alpha1.ProfileKind},
+		})
+
+		_, err := hook.Review(types.CreateOperation, res)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		hook := newHook(t, `exec sleep 5`, admissionadapter.Config{Timeout: "50ms"})
+
+		_, err := hook.Review(types.CreateOperation, res)
+		assert.ErrorContains(t, err, "timed out")
+	})
+}
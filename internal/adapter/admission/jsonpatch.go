@@ -0,0 +1,303 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admissionadapter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+)
+
+var errPathNotFound = errors.New("path does not exist")
+
+// applyPatch applies a JSON Patch (RFC 6902) to a JSON document.
+func applyPatch(doc []byte, patch []types.PatchOperation) ([]byte, error) {
+	var v any
+	if err := json.Unmarshal(doc, &v); err != nil {
+		return nil, err
+	}
+
+	for i, op := range patch {
+		var err error
+		if v, err = applyOperation(v, op); err != nil {
+			return nil, flaterrors.Join(
+				err,
+				types.ErrVal,
+				fmt.Errorf("cannot apply patch operation %d: %q %q", i, op.Op, op.Path),
+			)
+		}
+	}
+
+	return json.Marshal(v)
+}
+
+// applyOperation applies a JSON Patch operation to doc and returns the patched document.
+func applyOperation(doc any, op types.PatchOperation) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add":
+		return setValue(doc, path, op.Value, true)
+	case "remove":
+		out, _, err := removeValue(doc, path)
+		return out, err
+	case "replace":
+		if _, err := getValue(doc, path); err != nil {
+			return nil, err
+		}
+
+		return setValue(doc, path, op.Value, false)
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+
+		// -- a value cannot be moved into one of its children.
+		if op.Op == "move" && len(from) < len(path) && slices.Equal(from, path[:len(from)]) {
+			return nil, fmt.Errorf("cannot move %q into one of its children", op.From)
+		}
+
+		var value any
+		if op.Op == "move" {
+			doc, value, err = removeValue(doc, from)
+		} else {
+			value, err = getValue(doc, from)
+			value = deepCopy(value)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return setValue(doc, path, value, true)
+	case "test":
+		value, err := getValue(doc, path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !reflect.DeepEqual(value, op.Value) {
+			return nil, fmt.Errorf("test failed: got %v, want %v", value, op.Value)
+		}
+
+		return doc, nil
+	default:
+		return nil, fmt.Errorf("unsupported operation %q", op.Op)
+	}
+}
+
+// parsePointer parses a JSON Pointer (RFC 6901) into its reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+// getValue returns the value of doc at path.
+func getValue(doc any, path []string) (any, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, errPathNotFound
+			}
+
+			doc = value
+		case []any:
+			i, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+
+			doc = node[i]
+		default:
+			return nil, errPathNotFound
+		}
+	}
+
+	return doc, nil
+}
+
+// setValue sets the value of doc at path and returns the patched document. If insert is true,
+// values are inserted into arrays instead of replacing their elements.
+func setValue(doc any, path []string, value any, insert bool) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	token, last := path[0], len(path) == 1
+
+	switch node := doc.(type) {
+	case map[string]any:
+		if last {
+			node[token] = value
+			return node, nil
+		}
+
+		child, ok := node[token]
+		if !ok {
+			return nil, errPathNotFound
+		}
+
+		child, err := setValue(child, path[1:], value, insert)
+		if err != nil {
+			return nil, err
+		}
+
+		node[token] = child
+
+		return node, nil
+	case []any:
+		if last && insert {
+			i := len(node)
+			if token != "-" {
+				var err error
+				if i, err = arrayIndex(token, len(node)); err != nil {
+					return nil, err
+				}
+			}
+
+			return append(node[:i], append([]any{value}, node[i:]...)...), nil
+		}
+
+		i, err := arrayIndex(token, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+
+		if last {
+			node[i] = value
+			return node, nil
+		}
+
+		if node[i], err = setValue(node[i], path[1:], value, insert); err != nil {
+			return nil, err
+		}
+
+		return node, nil
+	default:
+		return nil, errPathNotFound
+	}
+}
+
+// removeValue removes the value of doc at path, and returns the patched document and the removed
+// value.
+func removeValue(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, errors.New("cannot remove the whole document")
+	}
+
+	token, last := path[0], len(path) == 1
+
+	switch node := doc.(type) {
+	case map[string]any:
+		child, ok := node[token]
+		if !ok {
+			return nil, nil, errPathNotFound
+		}
+
+		if last {
+			delete(node, token)
+			return node, child, nil
+		}
+
+		child, removed, err := removeValue(child, path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+
+		node[token] = child
+
+		return node, removed, nil
+	case []any:
+		i, err := arrayIndex(token, len(node)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if last {
+			removed := node[i]
+			return append(node[:i], node[i+1:]...), removed, nil
+		}
+
+		child, removed, err := removeValue(node[i], path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+
+		node[i] = child
+
+		return node, removed, nil
+	default:
+		return nil, nil, errPathNotFound
+	}
+}
+
+// arrayIndex parses an array index, which must not be greater than maxIndex.
+func arrayIndex(token string, maxIndex int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > maxIndex || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+
+	return i, nil
+}
+
+// deepCopy returns a deep copy of a decoded JSON value.
+func deepCopy(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(value))
+		for k, elem := range value {
+			out[k] = deepCopy(elem)
+		}
+
+		return out
+	case []any:
+		out := make([]any, len(value))
+		for i, elem := range value {
+			out[i] = deepCopy(elem)
+		}
+
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,308 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admissionadapter_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	admissionadapter "github.com/alexandremahdhaoui/vib/internal/adapter/admission"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// The test cases follow the examples of RFC 6902, Appendix A.
+func TestApplyPatch(t *testing.T) {
+	for _, tc := range []struct {
+		Name  string
+		Doc   string
+		Patch string
+		Want  string
+	}{
+		{
+			Name:  "AddObjectMember",
+			Doc:   `{"foo":"bar"}`,
+			Patch: `[{"op":"add","path":"/baz","value":"qux"}]`,
+			Want:  `{"baz":"qux","foo":"bar"}`,
+		},
+
+		{
+			Name:  "AddArrayElement",
+			Doc:   `{"foo":["bar","baz"]}`,
+			Patch: `[{"op":"add","path":"/foo/1","value":"qux"}]`,
+			Want:  `{"foo":["bar","qux","baz"]}`,
+		},
+
+		{
+			Name:  "AddArrayElementAtEnd",
+			Doc:   `{"foo":["bar"]}`,
+			Patch: `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`,
+			Want:  `{"foo":["bar",["abc","def"]]}`,
+		},
+
+		{
+			Name:  "AddNestedMember",
+			Doc:   `{"foo":"bar"}`,
+			Patch: `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`,
+			Want:  `{"child":{"grandchild":{}},"foo":"bar"}`,
+		},
+
+		{
+			Name:  "AddReplacesExistingMember",
+			Doc:   `{"foo":"bar"}`,
+			Patch: `[{"op":"add","path":"/foo","value":"baz"}]`,
+			Want:  `{"foo":"baz"}`,
+		},
+
+		{
+			Name:  "AddWholeDocument",
+			Doc:   `{"foo":"bar"}`,
+			Patch: `[{"op":"add","path":"","value":{"baz":"qux"}}]`,
+			Want:  `{"baz":"qux"}`,
+		},
+
+		{
+			Name:  "RemoveObjectMember",
+			Doc:   `{"baz":"qux","foo":"bar"}`,
+			Patch: `[{"op":"remove","path":"/baz"}]`,
+			Want:  `{"foo":"bar"}`,
+		},
+
+		{
+			Name:  "RemoveArrayElement",
+			Doc:   `{"foo":["bar","qux","baz"]}`,
+			Patch: `[{"op":"remove","path":"/foo/1"}]`,
+			Want:  `{"foo":["bar","baz"]}`,
+		},
+
+		{
+			Name:  "Replace",
+			Doc:   `{"baz":"qux","foo":"bar"}`,
+			Patch: `[{"op":"replace","path":"/baz","value":"boo"}]`,
+			Want:  `{"baz":"boo","foo":"bar"}`,
+		},
+
+		{
+			Name:  "ReplaceArrayElement",
+			Doc:   `{"foo":["bar","baz"]}`,
+			Patch: `[{"op":"replace","path":"/foo/0","value":"qux"}]`,
+			Want:  `{"foo":["qux","baz"]}`,
+		},
+
+		{
+			Name:  "Move",
+			Doc:   `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			Patch: `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			Want:  `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`,
+		},
+
+		{
+			Name:  "MoveArrayElement",
+			Doc:   `{"foo":["all","grass","cows","eat"]}`,
+			Patch: `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+			Want:  `{"foo":["all","cows","eat","grass"]}`,
+		},
+
+		{
+			Name:  "MoveToItself",
+			Doc:   `{"foo":{"bar":"baz"}}`,
+			Patch: `[{"op":"move","from":"/foo","path":"/foo"}]`,
+			Want:  `{"foo":{"bar":"baz"}}`,
+		},
+
+		{
+			Name:  "Copy",
+			Doc:   `{"foo":{"bar":["baz"]}}`,
+			Patch: `[{"op":"copy","from":"/foo","path":"/qux"},{"op":"add","path":"/qux/bar/-","value":"corge"}]`,
+			Want:  `{"foo":{"bar":["baz"]},"qux":{"bar":["baz","corge"]}}`,
+		},
+
+		{
+			Name:  "CopyArrayElement",
+			Doc:   `{"foo":["bar","baz"]}`,
+			Patch: `[{"op":"copy","from":"/foo/1","path":"/foo/0"}]`,
+			Want:  `{"foo":["baz","bar","baz"]}`,
+		},
+
+		{
+			Name: "Test",
+			Doc:  `{"baz":"qux","foo":["a",2,"c"]}`,
+			Patch: `[{"op":"test","path":"/baz","value":"qux"},` +
+				`{"op":"test","path":"/foo/1","value":2},` +
+				`{"op":"test","path":"/foo","value":["a",2,"c"]}]`,
+			Want: `{"baz":"qux","foo":["a",2,"c"]}`,
+		},
+
+		{
+			Name:  "EscapedPointer",
+			Doc:   `{"/":9,"~1":10}`,
+			Patch: `[{"op":"test","path":"/~01","value":10},{"op":"replace","path":"/~1","value":11}]`,
+			Want:  `{"/":11,"~1":10}`,
+		},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			var patch []types.PatchOperation
+			assert.NoError(t, json.Unmarshal([]byte(tc.Patch), &patch))
+
+			got, err := admissionadapter.ApplyPatch([]byte(tc.Doc), patch)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tc.Want, string(got))
+		})
+	}
+}
+
+func TestApplyPatch_Errors(t *testing.T) {
+	for _, tc := range []struct {
+		Name  string
+		Doc   string
+		Patch string
+	}{
+		{
+			Name:  "AddToNonexistentTarget",
+			Doc:   `{"foo":"bar"}`,
+			Patch: `[{"op":"add","path":"/baz/bat","value":"qux"}]`,
+		},
+
+		{
+			Name:  "AddOutOfRangeIndex",
+			Doc:   `{"foo":["bar"]}`,
+			Patch: `[{"op":"add","path":"/foo/2","value":"qux"}]`,
+		},
+
+		{
+			Name:  "AddNegativeIndex",
+			Doc:   `{"foo":["bar"]}`,
+			Patch: `[{"op":"add","path":"/foo/-1","value":"qux"}]`,
+		},
+
+		{
+			Name:  "AddLeadingZeroIndex",
+			Doc:   `{"foo":["bar","baz"]}`,
+			Patch: `[{"op":"add","path":"/foo/01","value":"qux"}]`,
+		},
+
+		{
+			Name:  "AddIntoScalar",
+			Doc:   `{"foo":"bar"}`,
+			Patch: `[{"op":"add","path":"/foo/baz","value":"qux"}]`,
+		},
+
+		{
+			Name:  "InvalidPointer",
+			Doc:   `{"foo":"bar"}`,
+			Patch: `[{"op":"add","path":"foo","value":"qux"}]`,
+		},
+
+		{
+			Name:  "RemoveNonexistentMember",
+			Doc:   `{"foo":"bar"}`,
+			Patch: `[{"op":"remove","path":"/baz"}]`,
+		},
+
+		{
+			Name:  "RemoveOutOfRangeIndex",
+			Doc:   `{"foo":["bar"]}`,
+			Patch: `[{"op":"remove","path":"/foo/1"}]`,
+		},
+
+		{
+			Name:  "RemoveEndOfArray",
+			Doc:   `{"foo":["bar"]}`,
+			Patch: `[{"op":"remove","path":"/foo/-"}]`,
+		},
+
+		{
+			Name:  "RemoveWholeDocument",
+			Doc:   `{"foo":"bar"}`,
+			Patch: `[{"op":"remove","path":""}]`,
+		},
+
+		{
+			Name:  "ReplaceNonexistentMember",
+			Doc:   `{"foo":"bar"}`,
+			Patch: `[{"op":"replace","path":"/baz","value":"qux"}]`,
+		},
+
+		{
+			Name:  "ReplaceOutOfRangeIndex",
+			Doc:   `{"foo":["bar"]}`,
+			Patch: `[{"op":"replace","path":"/foo/1","value":"qux"}]`,
+		},
+
+		{
+			Name:  "MoveNonexistentSource",
+			Doc:   `{"foo":"bar"}`,
+			Patch: `[{"op":"move","from":"/baz","path":"/qux"}]`,
+		},
+
+		{
+			Name:  "MoveIntoChild",
+			Doc:   `{"foo":{"bar":"baz"}}`,
+			Patch: `[{"op":"move","from":"/foo","path":"/foo/bar/qux"}]`,
+		},
+
+		{
+			Name:  "MoveInvalidFromPointer",
+			Doc:   `{"foo":"bar"}`,
+			Patch: `[{"op":"move","from":"foo","path":"/qux"}]`,
+		},
+
+		{
+			Name:  "CopyNonexistentSource",
+			Doc:   `{"foo":"bar"}`,
+			Patch: `[{"op":"copy","from":"/baz","path":"/qux"}]`,
+		},
+
+		{
+			Name:  "CopyToNonexistentTarget",
+			Doc:   `{"foo":"bar"}`,
+			Patch: `[{"op":"copy","from":"/foo","path":"/baz/qux"}]`,
+		},
+
+		{
+			Name:  "TestFailed",
+			Doc:   `{"baz":"qux"}`,
+			Patch: `[{"op":"test","path":"/baz","value":"bar"}]`,
+		},
+
+		{
+			Name:  "TestStringAgainstNumber",
+			Doc:   `{"/":9,"~1":10}`,
+			Patch: `[{"op":"test","path":"/~01","value":"10"}]`,
+		},
+
+		{
+			Name:  "TestNonexistentMember",
+			Doc:   `{"foo":"bar"}`,
+			Patch: `[{"op":"test","path":"/baz","value":null}]`,
+		},
+
+		{
+			Name:  "UnsupportedOperation",
+			Doc:   `{"foo":"bar"}`,
+			Patch: `[{"op":"merge","path":"/foo","value":"baz"}]`,
+		},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			var patch []types.PatchOperation
+			assert.NoError(t, json.Unmarshal([]byte(tc.Patch), &patch))
+
+			_, err := admissionadapter.ApplyPatch([]byte(tc.Doc), patch)
+			assert.ErrorIs(t, err, types.ErrVal)
+		})
+	}
+}
@@ -0,0 +1,313 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bundleadapter
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+
+	// INFO: Package "sigs.k8s.io/yaml" ensures that json tags specifying
+	// omitempty are respected
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ManifestFilename is the name of the manifest file of a bundle.
+	ManifestFilename = "manifest.yaml"
+
+	resourcesDir = "resources"
+)
+
+var (
+	errInvalidBundle = errors.New("invalid bundle")
+	errChecksum      = errors.New("checksum mismatch")
+)
+
+// Manifest describes the content of a bundle.
+type Manifest struct {
+	// APIVersions lists the API versions of the bundled resources.
+	APIVersions []types.APIVersion `json:"apiVersions"`
+	// Kinds lists the kinds of the bundled resources.
+	Kinds []types.Kind `json:"kinds"`
+	// Resources lists the bundled resources.
+	Resources []ManifestEntry `json:"resources"`
+}
+
+// ManifestEntry describes a bundled resource.
+type ManifestEntry struct {
+	APIVersion types.APIVersion `json:"apiVersion"`
+	Kind       types.Kind       `json:"kind"`
+	Name       string           `json:"name"`
+	Namespace  string           `json:"namespace"`
+	// Path is the path of the resource in the bundle.
+	Path string `json:"path"`
+	// SHA256 is the hex-encoded sha256 checksum of the file at Path.
+	SHA256 string `json:"sha256"`
+}
+
+// Write writes the resources to w as a gzipped tarball. Resources are encoded with the provided
+// codec.
+func Write(
+	w io.Writer,
+	codec types.Codec,
+	resources []types.Resource[types.APIVersionKind],
+) error {
+	manifest := Manifest{
+		APIVersions: make([]types.APIVersion, 0),
+		Kinds:       make([]types.Kind, 0),
+		Resources:   make([]ManifestEntry, 0, len(resources)),
+	}
+
+	files := make(map[string][]byte, len(resources))
+	for _, res := range resources {
+		nsName := types.NewNamespacedNameFromMetadata(res.Metadata)
+
+		// -- resourceVersions are local to a storage.
+		res.Metadata.ResourceVersion = ""
+
+		b, err := codec.Marshal(res)
+		if err != nil {
+			return err
+		}
+
+		entry := ManifestEntry{
+			APIVersion: res.APIVersion,
+			Kind:       res.Kind,
+			Name:       nsName.Name,
+			Namespace:  nsName.Namespace,
+			Path: path.Join(resourcesDir, nsName.Namespace, strings.ToLower(fmt.Sprintf(
+				"%s.%s.%s.%s",
+				strings.ReplaceAll(res.APIVersion, "/", "_"),
+				res.Kind,
+				nsName.Name,
+				codec.Encoding(),
+			))),
+			SHA256: checksum(b),
+		}
+
+		if _, ok := files[entry.Path]; ok {
+			return flaterrors.Join(
+				types.ErrExists,
+				fmt.Errorf("duplicate resource %q", entry.Path),
+			)
+		}
+
+		files[entry.Path] = b
+		manifest.Resources = append(manifest.Resources, entry)
+
+		if !slices.Contains(manifest.APIVersions, res.APIVersion) {
+			manifest.APIVersions = append(manifest.APIVersions, res.APIVersion)
+		}
+
+		if !slices.Contains(manifest.Kinds, res.Kind) {
+			manifest.Kinds = append(manifest.Kinds, res.Kind)
+		}
+	}
+
+	b, err := yaml.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	if err := writeFile(tw, ManifestFilename, b); err != nil {
+		return err
+	}
+
+	for _, entry := range manifest.Resources {
+		if err := writeFile(tw, entry.Path, files[entry.Path]); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	return gw.Close()
+}
+
+// Read reads a bundle from r. It verifies the bundle is consistent with its manifest, then decodes
+// and validates every bundled resource. Resources are returned in the order of the manifest.
+func Read(
+	r io.Reader,
+	decoder types.DynamicDecoder[types.APIVersionKind],
+) (Manifest, []types.Resource[types.APIVersionKind], error) {
+	files, err := readFiles(r)
+	if err != nil {
+		return Manifest{}, nil, flaterrors.Join(err, errInvalidBundle)
+	}
+
+	b, ok := files[ManifestFilename]
+	if !ok {
+		return Manifest{}, nil, flaterrors.Join(
+			fmt.Errorf("cannot find %q", ManifestFilename),
+			errInvalidBundle,
+		)
+	}
+
+	manifest := Manifest{}
+	if err := yaml.UnmarshalStrict(b, &manifest); err != nil {
+		return Manifest{}, nil, flaterrors.Join(err, errInvalidBundle)
+	}
+
+	delete(files, ManifestFilename)
+
+	var errs error
+	out := make([]types.Resource[types.APIVersionKind], 0, len(manifest.Resources))
+	for _, entry := range manifest.Resources {
+		res, err := readEntry(entry, files, decoder)
+		if err != nil {
+			errs = flaterrors.Join(errs, flaterrors.Join(err, fmt.Errorf("in %q", entry.Path)))
+			continue
+		}
+
+		delete(files, entry.Path)
+		out = append(out, res)
+	}
+
+	for filename := range files {
+		errs = flaterrors.Join(
+			errs,
+			fmt.Errorf("file %q is not listed in the manifest", filename),
+		)
+	}
+
+	if errs != nil {
+		return Manifest{}, nil, flaterrors.Join(errs, errInvalidBundle)
+	}
+
+	return manifest, out, nil
+}
+
+// readEntry verifies and decodes the resource described by entry.
+func readEntry(
+	entry ManifestEntry,
+	files map[string][]byte,
+	decoder types.DynamicDecoder[types.APIVersionKind],
+) (types.Resource[types.APIVersionKind], error) {
+	b, ok := files[entry.Path]
+	if !ok {
+		return types.Resource[types.APIVersionKind]{}, errors.New("cannot find file")
+	}
+
+	if sum := checksum(b); sum != entry.SHA256 {
+		return types.Resource[types.APIVersionKind]{}, flaterrors.Join(
+			errChecksum,
+			fmt.Errorf("expected %q; got %q", entry.SHA256, sum),
+		)
+	}
+
+	list, err := decoder.Decode(bytes.NewReader(b))
+	if err != nil {
+		return types.Resource[types.APIVersionKind]{}, err
+	}
+
+	if len(list) != 1 {
+		return types.Resource[types.APIVersionKind]{}, fmt.Errorf(
+			"expected exactly one resource; got %d",
+			len(list),
+		)
+	}
+
+	res := list[0]
+	nsName := types.NewNamespacedNameFromMetadata(res.Metadata)
+	if res.APIVersion != entry.APIVersion ||
+		res.Kind != entry.Kind ||
+		nsName.Name != entry.Name ||
+		nsName.Namespace != entry.Namespace {
+		return types.Resource[types.APIVersionKind]{}, errors.New(
+			"resource does not match its manifest entry",
+		)
+	}
+
+	if err := types.ValidateResource(res); err != nil {
+		return types.Resource[types.APIVersionKind]{}, err
+	}
+
+	return res, nil
+}
+
+// readFiles reads all the regular files of a gzipped tarball.
+func readFiles(r io.Reader) (map[string][]byte, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close() //nolint: errcheck
+
+	out := make(map[string][]byte)
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		b, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+
+		out[path.Clean(hdr.Name)] = b
+	}
+
+	return out, nil
+}
+
+// writeFile writes a regular file to the tarball. The modification time is zeroed to make bundles
+// reproducible.
+func writeFile(tw *tar.Writer, name string, b []byte) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0o640,
+		Size:     int64(len(b)),
+		ModTime:  time.Unix(0, 0),
+	}); err != nil {
+		return err
+	}
+
+	_, err := tw.Write(b)
+	return err
+}
+
+func checksum(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
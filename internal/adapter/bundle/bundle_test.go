@@ -0,0 +1,74 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bundleadapter_test
+
+import (
+	"bytes"
+	"testing"
+
+	bundleadapter "github.com/alexandremahdhaoui/vib/internal/adapter/bundle"
+	codecadapter "github.com/alexandremahdhaoui/vib/internal/adapter/codec"
+	"github.com/alexandremahdhaoui/vib/internal/service"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+	"github.com/alexandremahdhaoui/vib/pkg/apis/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBundle(t *testing.T) {
+	var drd types.DynamicDecoder[types.APIVersionKind]
+
+	setup := func(t *testing.T) {
+		t.Helper()
+
+		apiServer := service.NewAPIServer()
+		v1alpha1.RegisterWithManager(apiServer)
+
+		drd = codecadapter.NewDynamicResourceDecoder(apiServer)
+	}
+
+	resources := []types.Resource[types.APIVersionKind]{
+		{
+			APIVersion: v1alpha1.APIVersion,
+			Kind:       v1alpha1.ProfileKind,
+			Metadata:   types.Metadata{Name: "test", Namespace: "team-a", ResourceVersion: "3"},
+			Spec: &v1alpha1.ProfileSpec{
+				Refs: []types.NamespacedName{{Name: "es", Namespace: "team-a"}},
+			},
+		},
+	}
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		setup(t)
+
+		buf := bytes.NewBuffer(nil)
+		assert.NoError(t, bundleadapter.Write(buf, codecadapter.NewYAML(), resources))
+
+		manifest, actual, err := bundleadapter.Read(buf, drd)
+		assert.NoError(t, err)
+		assert.Equal(t, []types.Kind{v1alpha1.ProfileKind}, manifest.Kinds)
+		assert.Len(t, actual, 1)
+		assert.Equal(t, resources[0].Spec, actual[0].Spec)
+		assert.Equal(t, "", actual[0].Metadata.ResourceVersion)
+	})
+
+	t.Run("InvalidBundle", func(t *testing.T) {
+		setup(t)
+
+		_, _, err := bundleadapter.Read(bytes.NewBufferString("not a bundle"), drd)
+		assert.Error(t, err)
+	})
+}
@@ -63,13 +63,37 @@ func NewYAML() types.Codec {
 type yamlCodec struct{}
 
 // Marshal implements the types.Codec interface.
+// If v holds the YAML document it was decoded from, its comments and formatting are preserved.
 func (s *yamlCodec) Marshal(v any) ([]byte, error) {
-	return yaml.Marshal(v)
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if holder, ok := v.(nodeHolder); ok && holder.Node() != nil {
+		return marshalWithNode(b, holder.Node())
+	}
+
+	return b, nil
 }
 
 // Unmarshal implements the types.Codec interface.
+// If v can hold the YAML document it is decoded from, the document is kept in v.
 func (s *yamlCodec) Unmarshal(data []byte, v any) error {
-	return yaml.Unmarshal(data, v)
+	if err := yaml.Unmarshal(data, v); err != nil {
+		return err
+	}
+
+	if setter, ok := v.(nodeSetter); ok {
+		node, err := unmarshalNode(data)
+		if err != nil {
+			return err
+		}
+
+		setter.SetNode(node)
+	}
+
+	return nil
 }
 
 // Encoding implements the types.Codec interface.
@@ -0,0 +1,109 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package codecadapter_test
+
+import (
+	"testing"
+
+	codecadapter "github.com/alexandremahdhaoui/vib/internal/adapter/codec"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+	"github.com/alexandremahdhaoui/vib/pkg/apis/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestYAMLCodec(t *testing.T) {
+	t.Run("PreserveComments", func(t *testing.T) {
+		codec := codecadapter.NewYAML()
+
+		input := `# team profile
+apiVersion: vib.amahdha.com/v1alpha1
+kind: Profile
+metadata:
+  name: team
+spec:
+  refs:
+  - name: aliases # shared aliases
+  - name: "git"
+`
+
+		res := types.Resource[types.APIVersionKind]{Spec: &v1alpha1.ProfileSpec{}}
+		assert.NoError(t, codec.Unmarshal([]byte(input), &res))
+		assert.NotNil(t, res.Node())
+
+		res.Metadata.Namespace = "default"
+		res.Spec.(*v1alpha1.ProfileSpec).Refs[1].Name = "github"
+
+		b, err := codec.Marshal(res)
+		assert.NoError(t, err)
+		assert.Equal(t, `# team profile
+apiVersion: vib.amahdha.com/v1alpha1
+kind: Profile
+metadata:
+  name: team
+  namespace: default
+spec:
+  refs:
+  - name: aliases # shared aliases
+  - name: "github"
+`, string(b))
+	})
+}
+
+func TestJSONCCodec(t *testing.T) {
+	codec := codecadapter.NewJSONC()
+
+	for _, tc := range []struct {
+		Name      string
+		Input     string
+		ExpectErr bool
+	}{
+		{
+			Name: "CommentsAndTrailingCommas",
+			Input: `{
+  // line comment
+  "name": /* block comment */ "test",
+}`,
+		},
+		{
+			Name:      "JSON5UnquotedKeys",
+			Input:     `{name: "test"}`,
+			ExpectErr: true,
+		},
+		{
+			Name:      "JSON5SingleQuotedStrings",
+			Input:     `{"name": 'test'}`,
+			ExpectErr: true,
+		},
+		{
+			Name:      "JSON5HexadecimalNumbers",
+			Input:     `{"name": "test", "number": 0x1}`,
+			ExpectErr: true,
+		},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			var actual types.Metadata
+			err := codec.Unmarshal([]byte(tc.Input), &actual)
+			if tc.ExpectErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, types.Metadata{Name: "test"}, actual)
+		})
+	}
+}
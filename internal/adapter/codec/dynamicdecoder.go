@@ -20,7 +20,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"reflect"
 
 	"github.com/alexandremahdhaoui/vib/internal/types"
 
@@ -28,18 +30,13 @@ import (
 	yaml "sigs.k8s.io/yaml/goyaml.v3"
 )
 
-// TODO: write unit tests
-
 // TODO: Not urgent:
 // - LTS would be adding Encoder/Decoder iface to "sigs.k8s.io/yaml"
 
-// -- utility interface
-type decoder interface {
-	Decode(v any) error
-}
-
 // NewDynamicResourceDecoder instantiates a new dynamic resource decoder. A dynamic resource decoder is a special codec
 // that can unmarshal one or many documents from any supported encoding.
+// Decoding is strict: unknown fields and type mismatches are reported as *types.DecodeError.
+// Decoded resources are converted to the preferred version of their kind.
 func NewDynamicResourceDecoder(
 	apiServer types.APIServer,
 ) types.DynamicDecoder[types.APIVersionKind] {
@@ -54,12 +51,13 @@ type rawDrd struct {
 }
 
 var (
-	errInputMustBeJsonOrYaml = errors.New("input must be json or yaml")
-	errDecodingInput         = errors.New("error decoding input")
-	errInputMustNotBeEmpty   = errors.New("input must not be empty")
+	errUnsupportedInput    = errors.New("input must be json, jsonc, toml or yaml")
+	errDecodingInput       = errors.New("error decoding input")
+	errInputMustNotBeEmpty = errors.New("input must not be empty")
 )
 
 // Decode implements the types.DynamicDecoder interface.
+// If reader has a Name method, e.g. *os.File, its name is used to report errors.
 func (d *rawDrd) Decode(reader io.Reader) ([]types.Resource[types.APIVersionKind], error) {
 	b, err := io.ReadAll(reader)
 	if err != nil {
@@ -70,83 +68,48 @@ func (d *rawDrd) Decode(reader io.Reader) ([]types.Resource[types.APIVersionKind
 		return nil, flaterrors.Join(errInputMustNotBeEmpty, errDecodingInput)
 	}
 
-	bCopy := make([]byte, len(b))
-	copied := copy(bCopy, b)
-	if copied < len(b) {
-		return nil, flaterrors.Join(errors.New("internal error"), errDecodingInput)
+	var file string
+	if named, ok := reader.(interface{ Name() string }); ok {
+		file = named.Name()
 	}
 
 	// -- [ json yaml ]
-	jsonBuf := bytes.NewBuffer(b)
-	yamlBuf := bytes.NewBuffer(bCopy)
-
-	for _, supportedDecoder := range []struct {
-		IsJSON        bool
-		Decoder       decoder
-		UnmarshalFunc func(b []byte, v any) error
-		MarshalFunc   func(v any) ([]byte, error)
-	}{
-		{
-			IsJSON:        true,
-			Decoder:       json.NewDecoder(jsonBuf),
-			UnmarshalFunc: json.Unmarshal,
-			MarshalFunc:   json.Marshal,
-		},
-
-		{
-			Decoder:       yaml.NewDecoder(yamlBuf),
-			UnmarshalFunc: yaml.Unmarshal,
-			MarshalFunc:   yaml.Marshal,
-		},
-	} {
-		objectList, err := decodeRaw(supportedDecoder.Decoder)
-		if len(objectList) > 0 && err != nil {
-			// -- input is json/yaml but received error while parsing
-			return nil, flaterrors.Join(err, errDecodingInput)
-		} else if err != nil {
-			// -- input not decoded: try another supported decoder
-			continue
+	documents, encoding, err := decodeDocuments(b)
+	if err != nil {
+		var decodeErr *types.DecodeError
+		if errors.As(err, &decodeErr) {
+			decodeErr.File = file
 		}
 
-		out := make([]types.Resource[types.APIVersionKind], 0)
-		for i, obj := range objectList {
-			if v, ok := obj["items"]; ok {
-				// TODO: handle list items
-				list, ok := v.([]map[string]any)
-				if !ok {
-					return nil, flaterrors.Join(
-						errors.New("expected list of items"),
-						types.ErrAtIndex(i),
-					)
-				}
-
-				for _, raw := range list {
-					item, err := d.decodeOne(raw)
-					if errors.Is(err, errNilResource) {
-						continue
-					} else if err != nil {
-						return nil, flaterrors.Join(err, types.ErrAtIndex(i))
-					}
-					out = append(out, item)
-				}
-				continue
-			}
+		return nil, flaterrors.Join(err, errDecodingInput)
+	}
 
-			item, err := d.decodeOne(obj)
+	out := make([]types.Resource[types.APIVersionKind], 0)
+	for i, document := range documents {
+		items, err := documentItems(document)
+		if err != nil {
+			return nil, withDocument(err, file, i, document)
+		}
+
+		for _, item := range items {
+			res, err := d.decodeOne(item.node, item.path)
 			if errors.Is(err, errNilResource) {
 				continue
 			} else if err != nil {
-				return nil, flaterrors.Join(err, types.ErrAtIndex(i))
+				return nil, withDocument(err, file, i, item.node)
 			}
-			out = append(out, item)
-		}
 
-		// -- At this point, the output can be safely returned
-		return out, nil
+			// -- preserve comments and formatting of YAML documents.
+			if encoding == types.YAMLEncoding {
+				res.SetNode(item.document())
+			}
+
+			out = append(out, res)
+		}
 	}
 
-	// -- invalid input
-	return nil, flaterrors.Join(errInputMustBeJsonOrYaml, errDecodingInput)
+	// -- At this point, the output can be safely returned
+	return out, nil
 }
 
 var (
@@ -155,10 +118,13 @@ var (
 	errNilResource         = errors.New("-- nil resource --")
 )
 
-func (d *rawDrd) decodeOne(v any) (types.Resource[types.APIVersionKind], error) {
-	m, ok := v.(map[string]any)
-	if !ok {
+// decodeOne decodes the resource of node. Unknown fields and type mismatches are reported with
+// their position in node, and their path is prefixed by path.
+func (d *rawDrd) decodeOne(node *yaml.Node, path string) (types.Resource[types.APIVersionKind], error) {
+	var m map[string]any
+	if err := node.Decode(&m); err != nil {
 		return types.Resource[types.APIVersionKind]{}, flaterrors.Join(
+			err,
 			errAssertingType,
 			errDecodingOneResource,
 		)
@@ -177,32 +143,199 @@ func (d *rawDrd) decodeOne(v any) (types.Resource[types.APIVersionKind], error)
 		return types.Resource[types.APIVersionKind]{}, err
 	}
 
+	if err := checkNode(node, reflect.ValueOf(&out), path); err != nil {
+		return types.Resource[types.APIVersionKind]{}, err
+	}
+
 	b, err := json.Marshal(m)
 	if err != nil {
 		return types.Resource[types.APIVersionKind]{}, err
 	}
 
-	if err = json.Unmarshal(b, &out); err != nil {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+
+	if err = dec.Decode(&out); err != nil {
+		return types.Resource[types.APIVersionKind]{}, err
+	}
+
+	// -- resources of former APIVersions are converted to the preferred version of their kind.
+	if out, err = d.apiServer.Convert(out, ""); err != nil {
+		return types.Resource[types.APIVersionKind]{}, err
+	}
+
+	if err := d.apiServer.Default(&out); err != nil {
 		return types.Resource[types.APIVersionKind]{}, err
 	}
 
 	return out, nil
 }
 
-// raw decoding into map[any]any
-func decodeRaw(d decoder) ([]map[string]any, error) {
-	out := make([]map[string]any, 0)
-	done := false
-	i := 0
-	for !done {
-		var v map[string]any
-		if err := d.Decode(&v); errors.Is(err, io.EOF) {
-			done = true // End of file/stream
+// documentItem is the node of a resource found in a document.
+type documentItem struct {
+	node *yaml.Node
+	path string
+}
+
+// document returns the node of the item as a YAML document.
+func (i documentItem) document() *yaml.Node {
+	if i.node.Kind == yaml.DocumentNode {
+		return i.node
+	}
+
+	return &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{i.node}}
+}
+
+// documentItems returns the resources of a document. A document is either a resource, a List of
+// resources (i.e. with kind "List" and "items"), or a sequence of resources.
+func documentItems(document *yaml.Node) ([]documentItem, error) {
+	root := document
+	if root.Kind == yaml.DocumentNode {
+		if len(root.Content) == 0 {
+			return nil, nil // ignore empty documents
+		}
+
+		root = root.Content[0]
+	}
+
+	switch root.Kind {
+	case yaml.SequenceNode:
+		return sequenceItems(root, ""), nil
+	case yaml.MappingNode:
+		items := mappingValue(root, "items")
+		kind := mappingValue(root, "kind")
+		if items == nil || (kind != nil && kind.Value != types.ListKind) {
+			return []documentItem{{node: document}}, nil
+		}
+
+		// -- the kind of a List may be omitted.
+		if err := checkNode(root, reflect.ValueOf(&types.List[any]{}), ""); err != nil {
+			return nil, err
+		}
+
+		return sequenceItems(items, "items"), nil
+	case yaml.ScalarNode:
+		if root.Tag == "!!null" {
+			return nil, nil
+		}
+	}
+
+	return nil, nodeTypeMismatch(root, "", "resource or list of resources")
+}
+
+// sequenceItems returns the items of a sequence of resources.
+func sequenceItems(node *yaml.Node, path string) []documentItem {
+	out := make([]documentItem, 0, len(node.Content))
+	for i, item := range node.Content {
+		out = append(out, documentItem{node: item, path: fmt.Sprintf("%s[%d]", path, i)})
+	}
+
+	return out
+}
+
+// mappingValue returns the value of key in a mapping node, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// decodeDocuments decodes the documents of b into YAML nodes, and returns the detected encoding.
+// Supported encodings are tried in the following order: a stream of JSON values, a JSONC value,
+// a TOML document and a stream of YAML documents. The positions of the nodes are relative to the
+// beginning of b.
+func decodeDocuments(b []byte) ([]*yaml.Node, types.Encoding, error) {
+	if documents, err := decodeJSONDocuments(b); err == nil {
+		return documents, types.JSONEncoding, nil
+	} else if len(documents) > 0 {
+		// -- input is json but received error while parsing
+		return nil, types.JSONEncoding, err
+	}
+
+	// -- comments and trailing commas are replaced by whitespaces: positions are unchanged.
+	if standardized, err := standardizeJSONC(b); err == nil {
+		if documents, err := decodeJSONDocuments(standardized); err == nil {
+			return documents, types.JSONCEncoding, nil
+		}
+	}
+
+	if document, err := decodeTOMLDocument(b); err == nil {
+		return []*yaml.Node{document}, types.TOMLEncoding, nil
+	} else if looksLikeTOML(b) {
+		// -- input is toml but received error while parsing
+		return nil, types.TOMLEncoding, tomlDecodeError(err)
+	}
+
+	documents := make([]*yaml.Node, 0)
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	for i := 0; ; i++ {
+		node := new(yaml.Node)
+		if err := dec.Decode(node); errors.Is(err, io.EOF) {
+			break // End of file/stream
 		} else if err != nil {
-			return nil, flaterrors.Join(err, types.ErrAtIndex(i))
+			if len(documents) == 0 {
+				return nil, types.YAMLEncoding, flaterrors.Join(err, errUnsupportedInput)
+			}
+
+			return nil, types.YAMLEncoding, &types.DecodeError{Document: i, Err: err}
 		}
-		i++
-		out = append(out, v)
+
+		documents = append(documents, node)
+	}
+
+	if len(documents) == 0 {
+		return nil, types.YAMLEncoding, errUnsupportedInput
+	}
+
+	return documents, types.YAMLEncoding, nil
+}
+
+// decodeJSONDocuments decodes a stream of JSON values into YAML nodes.
+func decodeJSONDocuments(b []byte) ([]*yaml.Node, error) {
+	documents := make([]*yaml.Node, 0)
+	dec := json.NewDecoder(bytes.NewReader(b))
+	for i := 0; ; i++ {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); errors.Is(err, io.EOF) {
+			break // End of file/stream
+		} else if err != nil {
+			return documents, &types.DecodeError{Document: i, Err: err}
+		}
+
+		node := new(yaml.Node)
+		if err := yaml.Unmarshal(raw, node); err != nil {
+			return documents, &types.DecodeError{Document: i, Err: err}
+		}
+
+		// -- compute the position of the document in the input.
+		start := int(dec.InputOffset()) - len(raw)
+		line := bytes.Count(b[:start], []byte("\n")) + 1
+		column := start - bytes.LastIndexByte(b[:start], '\n')
+		shiftNode(node, line, column)
+
+		documents = append(documents, node)
+	}
+
+	if len(documents) == 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	return documents, nil
+}
+
+// shiftNode moves node and its children to a document starting at line and column.
+func shiftNode(node *yaml.Node, line, column int) {
+	if node.Line == 1 {
+		node.Column += column - 1
+	}
+
+	node.Line += line - 1
+
+	for _, child := range node.Content {
+		shiftNode(child, line, column)
 	}
-	return out, nil
 }
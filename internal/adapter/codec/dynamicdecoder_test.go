@@ -28,10 +28,7 @@ import (
 )
 
 func TestDynamicResourceDecoder(t *testing.T) {
-	var (
-		drd types.DynamicDecoder[types.APIVersionKind]
-		_   = ""
-	)
+	var drd types.DynamicDecoder[types.APIVersionKind]
 
 	setup := func(t *testing.T) {
 		t.Helper()
@@ -42,25 +39,317 @@ func TestDynamicResourceDecoder(t *testing.T) {
 		drd = codecadapter.NewDynamicResourceDecoder(apiServer)
 	}
 
-	t.Run("Decode", func(t *testing.T) {
-		setup(t)
+	newExpressionSet := func(name string) types.Resource[types.APIVersionKind] {
+		return types.Resource[types.APIVersionKind]{
+			APIVersion: v1alpha1.APIVersion,
+			Kind:       v1alpha1.ExpressionSetKind,
+			Metadata:   types.Metadata{Name: name},
+			Spec:       &v1alpha1.ExpressionSetSpec{},
+		}
+	}
 
-		var expected any
-		buf := bytes.NewBufferString(`
+	t.Run("Decode", func(t *testing.T) {
+		for _, tc := range []struct {
+			name     string
+			input    string
+			expected []types.Resource[types.APIVersionKind]
+		}{
+			{
+				name: "Resource",
+				input: `apiVersion: vib.amahdha.com/v1alpha1
+kind: ExpressionSet
+metadata:
+  name: test-0
+`,
+				expected: []types.Resource[types.APIVersionKind]{newExpressionSet("test-0")},
+			},
+			{
+				name: "MultipleDocuments",
+				input: `---
+apiVersion: vib.amahdha.com/v1alpha1
+kind: ExpressionSet
+metadata:
+  name: test-0
+---
+---
+apiVersion: vib.amahdha.com/v1alpha1
+kind: ExpressionSet
+metadata:
+  name: test-1
+`,
+				expected: []types.Resource[types.APIVersionKind]{
+					newExpressionSet("test-0"),
+					newExpressionSet("test-1"),
+				},
+			},
+			{
+				name: "List",
+				input: `apiVersion: v1
+kind: List
+items:
+  - apiVersion: vib.amahdha.com/v1alpha1
+    kind: ExpressionSet
+    metadata:
+      name: test-0
+  - apiVersion: vib.amahdha.com/v1alpha1
+    kind: ExpressionSet
+    metadata:
+      name: test-1
+`,
+				expected: []types.Resource[types.APIVersionKind]{
+					newExpressionSet("test-0"),
+					newExpressionSet("test-1"),
+				},
+			},
+			{
+				name: "ListWithoutKind",
+				input: `
 ---
 items:
   - apiVersion: vib.amahdha.com/v1alpha1
     kind: ExpressionSet
     metadata:
-      name: ItemFromExpressionSet
+      name: test-0
   - apiVersion: vib.amahdha.com/v1alpha1
     kind: ExpressionSet
     metadata:
-      name: test-test
-`)
+      name: test-1
+`,
+				expected: []types.Resource[types.APIVersionKind]{
+					newExpressionSet("test-0"),
+					newExpressionSet("test-1"),
+				},
+			},
+			{
+				name:     "EmptyList",
+				input:    `{"apiVersion": "v1", "kind": "List", "items": []}`,
+				expected: []types.Resource[types.APIVersionKind]{},
+			},
+			{
+				name: "Sequence",
+				input: `- apiVersion: vib.amahdha.com/v1alpha1
+  kind: ExpressionSet
+  metadata:
+    name: test-0
+- apiVersion: vib.amahdha.com/v1alpha1
+  kind: ExpressionSet
+  metadata:
+    name: test-1
+`,
+				expected: []types.Resource[types.APIVersionKind]{
+					newExpressionSet("test-0"),
+					newExpressionSet("test-1"),
+				},
+			},
+			{
+				name: "JSONList",
+				input: `{"apiVersion": "v1", "kind": "List", "items": [
+  {"apiVersion": "vib.amahdha.com/v1alpha1", "kind": "ExpressionSet", "metadata": {"name": "test-0"}}
+]}
+[{"apiVersion": "vib.amahdha.com/v1alpha1", "kind": "ExpressionSet", "metadata": {"name": "test-1"}}]
+`,
+				expected: []types.Resource[types.APIVersionKind]{
+					newExpressionSet("test-0"),
+					newExpressionSet("test-1"),
+				},
+			},
+			{
+				name: "JSONC",
+				input: `{
+  // a comment
+  "apiVersion": "vib.amahdha.com/v1alpha1",
+  "kind": "ExpressionSet",
+  "metadata": {"name": "test-0",},
+}
+`,
+				expected: []types.Resource[types.APIVersionKind]{newExpressionSet("test-0")},
+			},
+			{
+				name: "TOML",
+				input: `apiVersion = "vib.amahdha.com/v1alpha1"
+kind = "ExpressionSet"
+
+[metadata]
+name = "test-0"
+`,
+				expected: []types.Resource[types.APIVersionKind]{newExpressionSet("test-0")},
+			},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				setup(t)
+
+				actual, err := drd.Decode(bytes.NewBufferString(tc.input))
+				assert.NoError(t, err)
+
+				for i := range actual {
+					actual[i].SetNode(nil)
+				}
+
+				assert.Equal(t, tc.expected, actual)
+			})
+		}
+	})
+
+	t.Run("ListRoundTrip", func(t *testing.T) {
+		setup(t)
+
+		expected := []types.Resource[types.APIVersionKind]{
+			newExpressionSet("test-0"),
+			newExpressionSet("test-1"),
+		}
+
+		for _, codec := range []types.Codec{
+			codecadapter.NewJSON(),
+			codecadapter.NewJSONC(),
+			codecadapter.NewTOML(),
+			codecadapter.NewYAML(),
+		} {
+			b, err := codec.Marshal(types.NewList(expected))
+			assert.NoError(t, err)
+
+			actual, err := drd.Decode(bytes.NewReader(b))
+			assert.NoError(t, err)
+
+			for i := range actual {
+				actual[i].SetNode(nil)
+			}
+
+			assert.Equal(t, expected, actual)
+		}
+	})
+
+	t.Run("Strict", func(t *testing.T) {
+		for _, tc := range []struct {
+			name     string
+			input    string
+			expected types.DecodeError
+		}{
+			{
+				name: "UnknownField",
+				input: `apiVersion: vib.amahdha.com/v1alpha1
+kind: ExpressionSet
+metadata:
+  name: test
+spec:
+  keyvalues: []
+`,
+				expected: types.DecodeError{Line: 6, Column: 3, Path: "spec.keyvalues"},
+			},
+			{
+				name: "TypeMismatch",
+				input: `apiVersion: vib.amahdha.com/v1alpha1
+kind: Profile
+metadata:
+  name: test
+---
+apiVersion: vib.amahdha.com/v1alpha1
+kind: ExpressionSet
+metadata:
+  name: test
+spec:
+  resolverRef:
+    namespace: [vib-system]
+`,
+				expected: types.DecodeError{
+					Document: 1,
+					Line:     12,
+					Column:   16,
+					Path:     "spec.resolverRef.namespace",
+				},
+			},
+			{
+				name: "ListItem",
+				input: `apiVersion: v1
+kind: List
+items:
+  - apiVersion: vib.amahdha.com/v1alpha1
+    kind: Profile
+    metadata:
+      name: test
+    spec:
+      refs: {}
+`,
+				expected: types.DecodeError{Line: 9, Column: 13, Path: "items[0].spec.refs"},
+			},
+			{
+				name:     "ListItemsNotSequence",
+				input:    `{"kind": "List", "items": {}}`,
+				expected: types.DecodeError{Line: 1, Column: 27, Path: "items"},
+			},
+			{
+				name: "JSON",
+				input: `{"apiVersion": "vib.amahdha.com/v1alpha1", "kind": "Profile", "metadata": {"name": "test"}}
+{"apiVersion": "vib.amahdha.com/v1alpha1", "kind": "Profile", "metadata": {"name": "test"},
+  "spec": {"ref": []}}
+`,
+				expected: types.DecodeError{Document: 1, Line: 3, Column: 12, Path: "spec.ref"},
+			},
+			{
+				name: "TOMLUnknownField",
+				input: `apiVersion = "vib.amahdha.com/v1alpha1"
+kind = "ExpressionSet"
+
+[metadata]
+name = "test"
+
+[spec]
+keyvalues = []
+`,
+				expected: types.DecodeError{Line: 8, Column: 1, Path: "spec.keyvalues"},
+			},
+			{
+				name: "TOMLTypeMismatch",
+				input: `apiVersion = "vib.amahdha.com/v1alpha1"
+kind = "ExpressionSet"
+metadata.name = "test"
+
+[spec.resolverRef]
+namespace = 1
+`,
+				expected: types.DecodeError{Line: 6, Column: 13, Path: "spec.resolverRef.namespace"},
+			},
+			{
+				name: "TOMLListItem",
+				input: `kind = "List"
+
+[[items]]
+apiVersion = "vib.amahdha.com/v1alpha1"
+kind = "Profile"
+metadata = { name = "test-0" }
+
+[[items]]
+apiVersion = "vib.amahdha.com/v1alpha1"
+kind = "Profile"
+
+[items.metadata]
+name = "test-1"
+
+[items.spec]
+refs = "test"
+`,
+				expected: types.DecodeError{Line: 16, Column: 8, Path: "items[1].spec.refs"},
+			},
+			{
+				name: "TOMLSyntax",
+				input: `apiVersion = "vib.amahdha.com/v1alpha1"
+kind = "ExpressionSet"
+metadata.name = test
+`,
+				expected: types.DecodeError{Line: 3, Column: 17},
+			},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				setup(t)
+
+				_, err := drd.Decode(bytes.NewBufferString(tc.input))
 
-		actual, err := drd.Decode(buf)
-		assert.NoError(t, err)
-		assert.Equal(t, expected, actual)
+				var actual *types.DecodeError
+				assert.ErrorAs(t, err, &actual)
+				assert.Equal(t, tc.expected.Document, actual.Document)
+				assert.Equal(t, tc.expected.Line, actual.Line)
+				assert.Equal(t, tc.expected.Column, actual.Column)
+				assert.Equal(t, tc.expected.Path, actual.Path)
+			})
+		}
 	})
 }
@@ -0,0 +1,68 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package codecadapter
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/alexandremahdhaoui/vib/internal/types"
+	"github.com/tailscale/hujson"
+)
+
+var _ types.Codec = &jsoncCodec{}
+
+// NewJSONC returns a new codec for JSON with comments and trailing commas. Other JSON5 extensions,
+// e.g. unquoted keys or single-quoted strings, are rejected.
+// Comments are not preserved: documents are marshaled as indented JSON.
+func NewJSONC() types.Codec {
+	return &jsoncCodec{}
+}
+
+// jsoncCodec implements the types.Codec interface for JSONC encoding.
+type jsoncCodec struct{}
+
+// Marshal implements the types.Codec interface.
+func (s *jsoncCodec) Marshal(v any) ([]byte, error) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(b, '\n'), nil
+}
+
+// Unmarshal implements the types.Codec interface.
+func (s *jsoncCodec) Unmarshal(data []byte, v any) error {
+	b, err := standardizeJSONC(data)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+// Encoding implements the types.Codec interface.
+func (s *jsoncCodec) Encoding() types.Encoding {
+	return types.JSONCEncoding
+}
+
+// standardizeJSONC converts JSONC to standard JSON. Comments and trailing commas are replaced
+// by whitespaces, thus the positions of values are unchanged.
+func standardizeJSONC(data []byte) ([]byte, error) {
+	return hujson.Standardize(bytes.Clone(data))
+}
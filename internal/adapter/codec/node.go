@@ -0,0 +1,160 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package codecadapter
+
+import (
+	"bytes"
+	"slices"
+
+	yaml "sigs.k8s.io/yaml/goyaml.v3"
+)
+
+// nodeHolder is implemented by values remembering the YAML document they were decoded from,
+// e.g. types.Resource.
+type nodeHolder interface {
+	Node() *yaml.Node
+}
+
+// nodeSetter is implemented by values remembering the YAML document they were decoded from,
+// e.g. *types.Resource.
+type nodeSetter interface {
+	SetNode(node *yaml.Node)
+}
+
+// unmarshalNode parses the first YAML document of data. It returns nil if data does not contain
+// a YAML mapping.
+func unmarshalNode(data []byte) (*yaml.Node, error) {
+	node := new(yaml.Node)
+	if err := yaml.Unmarshal(data, node); err != nil {
+		return nil, err
+	}
+
+	if node.Kind != yaml.DocumentNode ||
+		len(node.Content) == 0 ||
+		node.Content[0].Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	return node, nil
+}
+
+// marshalWithNode decorates the YAML document b with the comments, key ordering and scalar styles
+// of the original document.
+// The content of b is left unchanged.
+func marshalWithNode(b []byte, original *yaml.Node) ([]byte, error) {
+	node := new(yaml.Node)
+	if err := yaml.Unmarshal(b, node); err != nil {
+		return nil, err
+	}
+
+	mergeNode(node, original)
+
+	buf := new(bytes.Buffer)
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+	enc.CompactSeqIndent()
+
+	if err := enc.Encode(node); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// mergeNode recursively copies the comments, key ordering and styles of src into dst.
+// Values of dst are never modified, except for null values of keys that src does not specify,
+// which are dropped.
+func mergeNode(dst, src *yaml.Node) {
+	if dst == nil || src == nil {
+		return
+	}
+
+	dst.HeadComment = src.HeadComment
+	dst.LineComment = src.LineComment
+	dst.FootComment = src.FootComment
+
+	if dst.Kind != src.Kind {
+		return
+	}
+
+	switch dst.Kind {
+	case yaml.DocumentNode:
+		for i := range min(len(dst.Content), len(src.Content)) {
+			mergeNode(dst.Content[i], src.Content[i])
+		}
+	case yaml.SequenceNode:
+		dst.Style = src.Style
+		for i := range min(len(dst.Content), len(src.Content)) {
+			mergeNode(dst.Content[i], src.Content[i])
+		}
+	case yaml.MappingNode:
+		dst.Style = src.Style
+		mergeMappingNode(dst, src)
+	case yaml.ScalarNode:
+		// -- the style of src is only kept if it does not alter the value, e.g. a string that must
+		// be quoted is not turned into a plain scalar.
+		if dst.Tag == src.Tag && src.Style != 0 {
+			dst.Style = src.Style
+		}
+	}
+}
+
+// mergeMappingNode orders the keys of dst as they appear in src. Keys that are not specified in
+// src are kept at the end in their original order, or dropped if their value is null.
+func mergeMappingNode(dst, src *yaml.Node) {
+	srcIndex := make(map[string]int, len(src.Content)/2)
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		srcIndex[src.Content[i].Value] = i
+	}
+
+	type pair struct {
+		key, value *yaml.Node
+		index      int
+	}
+
+	pairs := make([]pair, 0, len(dst.Content)/2)
+	for i := 0; i+1 < len(dst.Content); i += 2 {
+		key, value := dst.Content[i], dst.Content[i+1]
+
+		j, ok := srcIndex[key.Value]
+		if !ok {
+			if value.Kind == yaml.ScalarNode && value.Tag == "!!null" {
+				continue
+			}
+
+			pairs = append(pairs, pair{key: key, value: value, index: len(src.Content) + i})
+			continue
+		}
+
+		mergeNode(key, src.Content[j])
+		mergeNode(value, src.Content[j+1])
+		pairs = append(pairs, pair{key: key, value: value, index: j})
+	}
+
+	slices.SortStableFunc(pairs, func(a, b pair) int {
+		return a.index - b.index
+	})
+
+	dst.Content = dst.Content[:0]
+	for _, p := range pairs {
+		dst.Content = append(dst.Content, p.key, p.value)
+	}
+}
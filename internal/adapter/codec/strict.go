@@ -0,0 +1,253 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package codecadapter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/alexandremahdhaoui/vib/internal/types"
+	yaml "sigs.k8s.io/yaml/goyaml.v3"
+)
+
+var (
+	errUnknownField = errors.New("unknown field")
+	errTypeMismatch = errors.New("type mismatch")
+
+	jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+)
+
+// checkNode returns a *types.DecodeError describing the first unknown field or type mismatch of node
+// against v.
+// A value is used rather than a type so that interfaces holding a concrete value, such as the spec
+// of a resource, are checked against their dynamic type.
+func checkNode(node *yaml.Node, v reflect.Value, path string) error {
+	if node.Kind == yaml.DocumentNode {
+		if len(node.Content) == 0 {
+			return nil
+		}
+
+		return checkNode(node.Content[0], v, path)
+	}
+
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return nil
+	}
+
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.Kind() == reflect.Interface && v.IsNil() {
+			return nil // any value is accepted
+		}
+
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			v = reflect.New(v.Type().Elem())
+		}
+
+		v = v.Elem()
+	}
+
+	// -- types decoding themselves are not checked.
+	if reflect.PointerTo(v.Type()).Implements(jsonUnmarshalerType) {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return nodeTypeMismatch(node, path, "object")
+		}
+
+		fields := structFields(v)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			fieldPath := joinFieldPath(path, key.Value)
+
+			field, ok := fields[key.Value]
+			if !ok {
+				return newNodeError(key, fieldPath, fmt.Errorf("%w %q", errUnknownField, key.Value))
+			}
+
+			if err := checkNode(value, field, fieldPath); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return nodeTypeMismatch(node, path, "object")
+		}
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			fieldPath := joinFieldPath(path, node.Content[i].Value)
+			if err := checkNode(node.Content[i+1], elem, fieldPath); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return checkScalarNode(node, path, "string", "!!str", "!!binary")
+		}
+
+		if node.Kind != yaml.SequenceNode {
+			return nodeTypeMismatch(node, path, "list")
+		}
+
+		for i, item := range node.Content {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := checkNode(item, elem, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.String:
+		return checkScalarNode(node, path, "string", "!!str")
+	case reflect.Bool:
+		return checkScalarNode(node, path, "boolean", "!!bool")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return checkScalarNode(node, path, "integer", "!!int")
+	case reflect.Float32, reflect.Float64:
+		return checkScalarNode(node, path, "number", "!!int", "!!float")
+	}
+
+	return nil
+}
+
+// structFields returns the fields of a struct indexed by their JSON name. Fields of embedded
+// structs without JSON name are inlined.
+func structFields(v reflect.Value) map[string]reflect.Value {
+	out := make(map[string]reflect.Value)
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if name == "" && field.Anonymous && field.Type.Kind() == reflect.Struct {
+			for k, v := range structFields(v.Field(i)) {
+				out[k] = v
+			}
+
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		out[name] = v.Field(i)
+	}
+
+	return out
+}
+
+// checkScalarNode returns an error if node is not a scalar with one of the specified tags.
+func checkScalarNode(node *yaml.Node, path, expected string, tags ...string) error {
+	if node.Kind == yaml.ScalarNode {
+		for _, tag := range tags {
+			if node.Tag == tag {
+				return nil
+			}
+		}
+	}
+
+	return nodeTypeMismatch(node, path, expected)
+}
+
+// nodeTypeMismatch returns an error describing an unexpected node.
+func nodeTypeMismatch(node *yaml.Node, path, expected string) error {
+	return newNodeError(
+		node,
+		path,
+		fmt.Errorf("%w: expected %s, got %s", errTypeMismatch, expected, describeNode(node)),
+	)
+}
+
+// describeNode returns a human-readable description of the type of node.
+func describeNode(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "list"
+	}
+
+	switch node.Tag {
+	case "!!str":
+		return "string"
+	case "!!bool":
+		return "boolean"
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	}
+
+	return fmt.Sprintf("%q", node.Tag)
+}
+
+// newNodeError returns a *types.DecodeError located at node.
+func newNodeError(node *yaml.Node, path string, err error) error {
+	return &types.DecodeError{
+		Line:   node.Line,
+		Column: node.Column,
+		Path:   path,
+		Err:    err,
+	}
+}
+
+// withDocument locates err in a document. If err is a *types.DecodeError, its file and document
+// index are set. Otherwise, err is wrapped into a *types.DecodeError located at the document.
+func withDocument(err error, file string, i int, document *yaml.Node) error {
+	var decodeErr *types.DecodeError
+	if errors.As(err, &decodeErr) {
+		decodeErr.File = file
+		decodeErr.Document = i
+
+		return err
+	}
+
+	out := &types.DecodeError{File: file, Document: i, Err: err}
+
+	if document.Kind == yaml.DocumentNode && len(document.Content) > 0 {
+		document = document.Content[0]
+	}
+
+	out.Line, out.Column = document.Line, document.Column
+
+	return out
+}
+
+// joinFieldPath appends a field to a path, e.g. "spec" and "resolverRef" => "spec.resolverRef".
+func joinFieldPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+
+	return fmt.Sprintf("%s.%s", path, field)
+}
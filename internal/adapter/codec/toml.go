@@ -0,0 +1,340 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package codecadapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+	yaml "sigs.k8s.io/yaml/goyaml.v3"
+)
+
+var (
+	_ types.Codec = &tomlCodec{}
+
+	errTOMLMustBeTable = errors.New("toml documents must be tables")
+)
+
+// NewTOML returns a new TOML codec.
+// Values are converted through their JSON representation, thus json tags are respected. As TOML
+// does not support null values, they are omitted.
+func NewTOML() types.Codec {
+	return &tomlCodec{}
+}
+
+// tomlCodec implements the types.Codec interface for TOML encoding.
+type tomlCodec struct{}
+
+// Marshal implements the types.Codec interface.
+func (s *tomlCodec) Marshal(v any) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var raw any
+	if err := dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	table, ok := tomlValue(raw).(map[string]any)
+	if !ok {
+		return nil, flaterrors.Join(errTOMLMustBeTable, types.ErrEncoding)
+	}
+
+	return toml.Marshal(table)
+}
+
+// Unmarshal implements the types.Codec interface.
+func (s *tomlCodec) Unmarshal(data []byte, v any) error {
+	table := make(map[string]any)
+	if err := toml.Unmarshal(data, &table); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(table)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+// Encoding implements the types.Codec interface.
+func (s *tomlCodec) Encoding() types.Encoding {
+	return types.TOMLEncoding
+}
+
+// tomlValue converts a value decoded from JSON to a value that can be encoded in TOML: null values
+// are dropped, and numbers are converted to integers when possible.
+func tomlValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, value := range v {
+			if value == nil {
+				continue
+			}
+
+			out[key] = tomlValue(value)
+		}
+
+		return out
+	case []any:
+		out := make([]any, 0, len(v))
+		for _, value := range v {
+			if value == nil {
+				continue
+			}
+
+			out = append(out, tomlValue(value))
+		}
+
+		return out
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+
+		f, _ := v.Float64()
+
+		return f
+	}
+
+	return v
+}
+
+// decodeTOMLDocument decodes a TOML document into a YAML node. It returns an error if b is not a
+// non-empty TOML document.
+// The lines and columns of the nodes are the positions of their keys and values in b.
+func decodeTOMLDocument(b []byte) (*yaml.Node, error) {
+	table := make(map[string]any)
+	if err := toml.Unmarshal(b, &table); err != nil {
+		return nil, err
+	}
+
+	if len(table) == 0 {
+		return nil, fmt.Errorf("empty toml document")
+	}
+
+	positions, err := tomlPositions(b)
+	if err != nil {
+		return nil, err
+	}
+
+	node := new(yaml.Node)
+	if err := node.Encode(table); err != nil {
+		return nil, err
+	}
+
+	node.Line, node.Column = 1, 1
+	setTOMLPositions(node, nil, positions)
+
+	return &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{node}}, nil
+}
+
+// tomlDecodeError locates err in the TOML document, if its position is known.
+func tomlDecodeError(err error) error {
+	out := &types.DecodeError{Document: 0, Err: err}
+
+	var decodeErr *toml.DecodeError
+	if errors.As(err, &decodeErr) {
+		out.Line, out.Column = decodeErr.Position()
+	}
+
+	return out
+}
+
+// tomlLineRegex matches the key/value pairs and the table headers of TOML documents.
+var tomlLineRegex = regexp.MustCompile(`^\s*(\[\[?\s*[\w"'.\- ]+\]\]?|[\w"'.\-]+\s*=)`)
+
+// looksLikeTOML returns true if the first line of b that is neither blank nor a comment is a TOML
+// key/value pair or table header.
+func looksLikeTOML(b []byte) bool {
+	for _, line := range bytes.Split(b, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		return tomlLineRegex.Match(line)
+	}
+
+	return false
+}
+
+// tomlPosition is the position of a key and of its value in a TOML document.
+type tomlPosition struct {
+	key   unstable.Position
+	value unstable.Position
+}
+
+// tomlPositions returns the positions of the keys and values of a TOML document, indexed by their
+// path. The elements of arrays, including arrays of tables, are indexed by their index.
+func tomlPositions(b []byte) (map[string]tomlPosition, error) {
+	p := new(unstable.Parser)
+	p.Reset(b)
+
+	w := &tomlPositionWalker{
+		parser:      p,
+		positions:   make(map[string]tomlPosition),
+		arrayTables: make(map[string]int),
+	}
+
+	var table []string
+	for p.NextExpression() {
+		expr := p.Expression()
+		switch expr.Kind {
+		case unstable.KeyValue:
+			w.keyValue(table, expr)
+		case unstable.Table:
+			table, _ = w.key(nil, expr.Key(), false)
+		case unstable.ArrayTable:
+			table, _ = w.key(nil, expr.Key(), true)
+		}
+	}
+
+	if err := p.Error(); err != nil {
+		return nil, err
+	}
+
+	return w.positions, nil
+}
+
+// tomlPositionWalker records the positions of the nodes of a TOML document.
+type tomlPositionWalker struct {
+	parser    *unstable.Parser
+	positions map[string]tomlPosition
+	// arrayTables holds the length of the arrays of tables, indexed by their path.
+	arrayTables map[string]int
+}
+
+// key records the positions of the parts of a dotted key relative to path, and returns the path of
+// the key and the position of its last part. If arrayTable is true, the key is the header of an
+// array of tables, and the returned path is the path of its new element.
+func (w *tomlPositionWalker) key(
+	path []string,
+	it unstable.Iterator,
+	arrayTable bool,
+) ([]string, unstable.Position) {
+	out := slices.Clone(path)
+
+	var pos unstable.Position
+	for it.Next() {
+		pos = w.parser.Shape(it.Node().Raw).Start
+		out = append(out, string(it.Node().Data))
+		pathKey := tomlPathKey(out)
+
+		if _, ok := w.positions[pathKey]; !ok {
+			w.positions[pathKey] = tomlPosition{key: pos, value: pos}
+		}
+
+		n, ok := w.arrayTables[pathKey]
+		if arrayTable && it.IsLast() {
+			w.arrayTables[pathKey] = n + 1
+			out = append(out, strconv.Itoa(n))
+			w.positions[tomlPathKey(out)] = tomlPosition{key: pos, value: pos}
+		} else if ok {
+			// -- keys of arrays of tables refer to their last element.
+			out = append(out, strconv.Itoa(n-1))
+		}
+	}
+
+	return out, pos
+}
+
+// keyValue records the positions of a key/value pair of the table at path.
+func (w *tomlPositionWalker) keyValue(path []string, node *unstable.Node) {
+	path, pos := w.key(path, node.Key(), false)
+	w.value(path, node.Value(), pos)
+}
+
+// value records the position of a value at path. The position of values without range, e.g.
+// arrays, is the position of their key.
+func (w *tomlPositionWalker) value(path []string, node *unstable.Node, key unstable.Position) {
+	pos := key
+	if node.Raw.Length > 0 {
+		pos = w.parser.Shape(node.Raw).Start
+	}
+
+	w.positions[tomlPathKey(path)] = tomlPosition{key: key, value: pos}
+
+	switch node.Kind {
+	case unstable.Array:
+		i := 0
+		for it := node.Children(); it.Next(); {
+			if it.Node().Kind == unstable.Comment {
+				continue
+			}
+
+			w.value(append(slices.Clone(path), strconv.Itoa(i)), it.Node(), pos)
+			i++
+		}
+	case unstable.InlineTable:
+		for it := node.Children(); it.Next(); {
+			if it.Node().Kind == unstable.KeyValue {
+				w.keyValue(path, it.Node())
+			}
+		}
+	}
+}
+
+// setTOMLPositions sets the lines and columns of the children of node at path.
+func setTOMLPositions(node *yaml.Node, path []string, positions map[string]tomlPosition) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			childPath := append(slices.Clone(path), key.Value)
+
+			if pos, ok := positions[tomlPathKey(childPath)]; ok {
+				key.Line, key.Column = pos.key.Line, pos.key.Column
+				value.Line, value.Column = pos.value.Line, pos.value.Column
+			}
+
+			setTOMLPositions(value, childPath, positions)
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			childPath := append(slices.Clone(path), strconv.Itoa(i))
+
+			if pos, ok := positions[tomlPathKey(childPath)]; ok {
+				child.Line, child.Column = pos.value.Line, pos.value.Column
+			}
+
+			setTOMLPositions(child, childPath, positions)
+		}
+	}
+}
+
+// tomlPathKey returns the key of path in the positions of a TOML document.
+func tomlPathKey(path []string) string {
+	return strings.Join(path, "\x00")
+}
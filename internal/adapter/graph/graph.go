@@ -0,0 +1,276 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package graphadapter
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+)
+
+// Format is the output format of a graph.
+type Format string
+
+const (
+	// DOTFormat is the Graphviz DOT format.
+	DOTFormat Format = "dot"
+	// MermaidFormat is the Mermaid flowchart format.
+	MermaidFormat Format = "mermaid"
+	// JSONFormat is a JSON document listing the nodes and edges of the graph.
+	JSONFormat Format = "json"
+)
+
+// Formats lists the supported formats.
+var Formats = []Format{DOTFormat, MermaidFormat, JSONFormat}
+
+type (
+	// Graph is the dependency graph of resources. Edges go from a resource to the resources it
+	// references.
+	Graph struct {
+		Nodes []Node `json:"nodes"`
+		Edges []Edge `json:"edges"`
+	}
+
+	// Node is a resource of the graph.
+	Node struct {
+		ID         string           `json:"id"`
+		APIVersion types.APIVersion `json:"apiVersion"`
+		Kind       types.Kind       `json:"kind"`
+		Name       string           `json:"name"`
+		Namespace  string           `json:"namespace"`
+		// Dangling is true if the resource is referenced but does not exist.
+		Dangling bool `json:"dangling,omitempty"`
+	}
+
+	// Edge is a reference from a resource to another.
+	Edge struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	}
+)
+
+// New builds the graph of roots and of the resources they transitively reference. References are
+// extracted with the types.ReferenceLister interface.
+func New(storage types.Storage, roots []types.Resource[types.APIVersionKind]) (*Graph, error) {
+	out := &Graph{Nodes: make([]Node, 0), Edges: make([]Edge, 0)}
+	ids := make(map[types.Reference]string)
+
+	addNode := func(ref types.Reference, dangling bool) (string, bool) {
+		if id, ok := ids[ref]; ok {
+			return id, false
+		}
+
+		id := fmt.Sprintf("n%d", len(out.Nodes))
+		ids[ref] = id
+		out.Nodes = append(out.Nodes, Node{
+			ID:         id,
+			APIVersion: ref.APIVersion,
+			Kind:       ref.Kind,
+			Name:       ref.NamespacedName.Name,
+			Namespace:  ref.NamespacedName.Namespace,
+			Dangling:   dangling,
+		})
+
+		return id, true
+	}
+
+	queue := slices.Clone(roots)
+	for _, res := range roots {
+		addNode(types.NewReferenceFromResource(res), false)
+	}
+
+	for len(queue) > 0 {
+		res := queue[0]
+		queue = queue[1:]
+
+		lister, ok := res.Spec.(types.ReferenceLister)
+		if !ok {
+			continue
+		}
+
+		from := ids[types.NewReferenceFromResource(res)]
+		for _, ref := range lister.References() {
+			if id, ok := ids[ref]; ok {
+				out.Edges = append(out.Edges, Edge{From: from, To: id})
+				continue
+			}
+
+			referenced, err := storage.Get(types.NewAPIVersionKind(ref.APIVersion, ref.Kind), ref.NamespacedName)
+			if err != nil && !errors.Is(err, types.ErrNotFound) {
+				return nil, err
+			}
+
+			id, _ := addNode(ref, err != nil)
+			out.Edges = append(out.Edges, Edge{From: from, To: id})
+
+			if err == nil {
+				queue = append(queue, referenced)
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// Encode writes the graph to w in the given format. Namespaces are rendered as clusters, and
+// dangling references are highlighted.
+func Encode(w io.Writer, g *Graph, format Format) error {
+	switch format {
+	case DOTFormat:
+		return encodeDOT(w, g)
+	case MermaidFormat:
+		return encodeMermaid(w, g)
+	case JSONFormat:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(g)
+	default:
+		return flaterrors.Join(
+			types.ErrEncoding,
+			fmt.Errorf("unrecognized graph format %q", format),
+		)
+	}
+}
+
+func encodeDOT(w io.Writer, g *Graph) error {
+	buf := new(strings.Builder)
+	buf.WriteString("digraph vib {\n  rankdir=LR;\n  node [shape=box];\n")
+
+	for i, namespace := range g.namespaces() {
+		fmt.Fprintf(buf, "\n  subgraph cluster_%d {\n    label=%q;\n", i, namespace)
+
+		for _, node := range g.nodesInNamespace(namespace) {
+			attrs := ""
+			if node.Dangling {
+				attrs = ", style=dashed, color=red, fontcolor=red"
+			}
+
+			fmt.Fprintf(buf, "    %s [label=%q%s];\n", node.ID, node.Kind+"\n"+node.Name, attrs)
+		}
+
+		buf.WriteString("  }\n")
+	}
+
+	buf.WriteString("\n")
+
+	dangling := g.danglingIDs()
+	for _, edge := range g.Edges {
+		attrs := ""
+		if _, ok := dangling[edge.To]; ok {
+			attrs = " [style=dashed, color=red]"
+		}
+
+		fmt.Fprintf(buf, "  %s -> %s%s;\n", edge.From, edge.To, attrs)
+	}
+
+	buf.WriteString("}\n")
+
+	_, err := io.WriteString(w, buf.String())
+
+	return err
+}
+
+func encodeMermaid(w io.Writer, g *Graph) error {
+	buf := new(strings.Builder)
+	buf.WriteString("flowchart LR\n")
+
+	for i, namespace := range g.namespaces() {
+		fmt.Fprintf(buf, "  subgraph ns%d [\"%s\"]\n", i, namespace)
+
+		for _, node := range g.nodesInNamespace(namespace) {
+			fmt.Fprintf(buf, "    %s[\"%s %s\"]\n", node.ID, node.Kind, node.Name)
+		}
+
+		buf.WriteString("  end\n")
+	}
+
+	dangling := g.danglingIDs()
+	for _, edge := range g.Edges {
+		arrow := "-->"
+		if _, ok := dangling[edge.To]; ok {
+			arrow = "-.->"
+		}
+
+		fmt.Fprintf(buf, "  %s %s %s\n", edge.From, arrow, edge.To)
+	}
+
+	if len(dangling) > 0 {
+		ids := make([]string, 0, len(dangling))
+		for _, node := range g.Nodes {
+			if node.Dangling {
+				ids = append(ids, node.ID)
+			}
+		}
+
+		buf.WriteString("  classDef dangling stroke:#f00,stroke-dasharray:5 5,color:#f00\n")
+		fmt.Fprintf(buf, "  class %s dangling\n", strings.Join(ids, ","))
+	}
+
+	_, err := io.WriteString(w, buf.String())
+
+	return err
+}
+
+// namespaces returns the sorted namespaces of the nodes.
+func (g *Graph) namespaces() []string {
+	out := make([]string, 0)
+	for _, node := range g.Nodes {
+		if !slices.Contains(out, node.Namespace) {
+			out = append(out, node.Namespace)
+		}
+	}
+
+	slices.Sort(out)
+
+	return out
+}
+
+// nodesInNamespace returns the nodes of a namespace, sorted by kind and name.
+func (g *Graph) nodesInNamespace(namespace string) []Node {
+	out := make([]Node, 0)
+	for _, node := range g.Nodes {
+		if node.Namespace == namespace {
+			out = append(out, node)
+		}
+	}
+
+	slices.SortFunc(out, func(a, b Node) int {
+		return cmp.Or(cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.Name, b.Name))
+	})
+
+	return out
+}
+
+// danglingIDs returns the IDs of the dangling nodes.
+func (g *Graph) danglingIDs() map[string]struct{} {
+	out := make(map[string]struct{})
+	for _, node := range g.Nodes {
+		if node.Dangling {
+			out[node.ID] = struct{}{}
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package graphadapter_test
+
+import (
+	"bytes"
+	"testing"
+
+	codecadapter "github.com/alexandremahdhaoui/vib/internal/adapter/codec"
+	graphadapter "github.com/alexandremahdhaoui/vib/internal/adapter/graph"
+	storageadapter "github.com/alexandremahdhaoui/vib/internal/adapter/storage"
+	"github.com/alexandremahdhaoui/vib/internal/service"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+	"github.com/alexandremahdhaoui/vib/pkg/apis/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraph(t *testing.T) {
+	apiServer := service.NewAPIServer()
+	v1alpha1.RegisterWithManager(apiServer)
+
+	storage, err := storageadapter.NewFilesystem(
+		apiServer,
+		codecadapter.NewYAML(),
+		codecadapter.NewDynamicResourceDecoder(apiServer),
+		t.TempDir(),
+		0,
+	)
+	assert.NoError(t, err)
+
+	resolver := v1alpha1.NewPlainResolver()
+	resolver.Metadata.Namespace = types.VibSystemNamespace
+
+	es := types.Resource[types.APIVersionKind]{
+		APIVersion: v1alpha1.APIVersion,
+		Kind:       v1alpha1.ExpressionSetKind,
+		Metadata:   types.Metadata{Name: "es", Namespace: types.DefaultNamespace},
+		Spec: &v1alpha1.ExpressionSetSpec{
+			ResolverRef: types.NewNamespacedNameFromMetadata(resolver.Metadata),
+		},
+	}
+
+	profile := types.Resource[types.APIVersionKind]{
+		APIVersion: v1alpha1.APIVersion,
+		Kind:       v1alpha1.ProfileKind,
+		Metadata:   types.Metadata{Name: "profile", Namespace: types.DefaultNamespace},
+		Spec: &v1alpha1.ProfileSpec{
+			Refs: []types.NamespacedName{{Name: "es"}, {Name: "missing"}},
+		},
+	}
+
+	for _, res := range []types.Resource[types.APIVersionKind]{resolver, es} {
+		assert.NoError(t, storage.Create(res))
+	}
+
+	g, err := graphadapter.New(storage, []types.Resource[types.APIVersionKind]{profile})
+	assert.NoError(t, err)
+	assert.Len(t, g.Nodes, 4)
+	assert.Len(t, g.Edges, 3)
+
+	dangling := make([]string, 0)
+	for _, node := range g.Nodes {
+		if node.Dangling {
+			dangling = append(dangling, node.Name)
+		}
+	}
+
+	assert.Equal(t, []string{"missing"}, dangling)
+
+	t.Run("DOT", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		assert.NoError(t, graphadapter.Encode(buf, g, graphadapter.DOTFormat))
+		assert.Contains(t, buf.String(), `label="vib-system";`)
+		assert.Contains(t, buf.String(), "[style=dashed, color=red]")
+	})
+
+	t.Run("UnknownFormat", func(t *testing.T) {
+		err := graphadapter.Encode(bytes.NewBuffer(nil), g, "svg")
+		assert.ErrorIs(t, err, types.ErrEncoding)
+	})
+}
@@ -0,0 +1,578 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shelladapter
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// StatementKind is the kind of a shell statement.
+type StatementKind string
+
+const (
+	// AliasStatement is an alias definition: `alias key='value'`.
+	AliasStatement StatementKind = "alias"
+	// ExportStatement is an exported variable assignment: `export KEY=value`.
+	ExportStatement StatementKind = "export"
+	// AssignmentStatement is a variable assignment: `KEY=value`.
+	AssignmentStatement StatementKind = "assignment"
+	// FunctionStatement is a function definition: `key() { value }` or `function key { value }`.
+	FunctionStatement StatementKind = "function"
+	// PlainStatement is any statement that cannot be represented by another kind.
+	PlainStatement StatementKind = "plain"
+)
+
+// Statement is a statement parsed from a shell rc file.
+type Statement struct {
+	// Kind is the kind of the statement.
+	Kind StatementKind
+	// Key is the name of the alias, variable or function. It is empty for plain statements.
+	Key string
+	// Value is the unquoted value of the alias or variable, or the body of the function. It is
+	// empty for plain statements.
+	Value string
+	// Raw is the original text of the statement.
+	Raw string
+}
+
+var (
+	heredocRegex   = regexp.MustCompile(`<<-?\s*['"]?([A-Za-z_][A-Za-z0-9_]*)['"]?`)
+	functionRegex  = regexp.MustCompile(`^\s*(?:function\s+([A-Za-z_][\w:.@+-]*)\s*(?:\(\s*\))?|([A-Za-z_][\w:.@+-]*)\s*\(\s*\))\s*`)
+	variableRegex  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	aliasNameRegex = regexp.MustCompile(`^[A-Za-z0-9_.:@%+,-]+$`)
+)
+
+// Parse parses a shell rc file into a list of statements, preserving their order.
+// Empty lines are dropped; comments and statements that cannot be parsed are returned as plain
+// statements.
+func Parse(r io.Reader) ([]Statement, error) {
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	p := &parser{lines: lines}
+	out := make([]Statement, 0)
+	for p.i < len(p.lines) {
+		line := p.lines[p.i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			p.i++
+		case strings.HasPrefix(trimmed, "#"):
+			p.i++
+			out = append(out, plain(line))
+		default:
+			out = append(out, p.parseStatement())
+		}
+	}
+
+	return out, nil
+}
+
+// parser holds the state of the parsing of a shell rc file.
+type parser struct {
+	lines []string
+	i     int
+}
+
+// parseStatement consumes the lines of the next statement and classifies it.
+func (p *parser) parseStatement() Statement {
+	raw, hasHeredoc := p.collect()
+
+	if m := functionRegex.FindStringSubmatch(raw); m != nil {
+		// -- the opening brace may be defined on the next line.
+		if strings.TrimSpace(raw[len(m[0]):]) == "" && p.i < len(p.lines) &&
+			strings.HasPrefix(strings.TrimSpace(p.lines[p.i]), "{") {
+			next, nextHasHeredoc := p.collect()
+			raw = raw + "\n" + next
+			hasHeredoc = hasHeredoc || nextHasHeredoc
+		}
+
+		name := m[1]
+		if name == "" {
+			name = m[2]
+		}
+
+		if body, ok := functionBody(raw[len(m[0]):]); ok {
+			return Statement{Kind: FunctionStatement, Key: name, Value: body, Raw: raw}
+		}
+
+		return plain(raw)
+	}
+
+	if hasHeredoc || strings.Contains(raw, "\n") {
+		return plain(raw)
+	}
+
+	words, ok := splitWords(raw)
+	if !ok || len(words) == 0 {
+		return plain(raw)
+	}
+
+	switch words[0].raw() {
+	case "alias":
+		if len(words) != 2 {
+			return plain(raw)
+		}
+
+		name, value, ok := words[1].splitAssignment()
+		if !ok || !aliasNameRegex.MatchString(name) {
+			return plain(raw)
+		}
+
+		s, ok := value.singleQuotable()
+		if !ok {
+			return plain(raw)
+		}
+
+		return Statement{Kind: AliasStatement, Key: name, Value: s, Raw: raw}
+
+	case "export":
+		if len(words) != 2 {
+			return plain(raw)
+		}
+
+		return assignment(ExportStatement, words[1], raw)
+
+	default:
+		if len(words) != 1 {
+			return plain(raw)
+		}
+
+		return assignment(AssignmentStatement, words[0], raw)
+	}
+}
+
+// collect consumes physical lines until the statement is complete, i.e. until quotes, braces,
+// parenthesis and compound commands are closed and no line continuation is pending.
+// Heredoc bodies are consumed verbatim.
+func (p *parser) collect() (string, bool) {
+	buf := make([]string, 0, 1)
+	scanned := make([]string, 0, 1)
+	hasHeredoc := false
+
+	for p.i < len(p.lines) {
+		line := p.lines[p.i]
+		p.i++
+
+		buf = append(buf, line)
+		scanned = append(scanned, line)
+
+		for _, m := range heredocRegex.FindAllStringSubmatch(line, -1) {
+			if strings.Contains(line, "<<<") {
+				break
+			}
+
+			hasHeredoc = true
+			for p.i < len(p.lines) {
+				body := p.lines[p.i]
+				p.i++
+				buf = append(buf, body)
+
+				if strings.TrimLeft(body, "\t") == m[1] {
+					break
+				}
+			}
+		}
+
+		if scan(strings.Join(scanned, "\n")).complete() {
+			break
+		}
+	}
+
+	return strings.Join(buf, "\n"), hasHeredoc
+}
+
+// assignment converts a "KEY=value" word into a statement of the provided kind.
+func assignment(kind StatementKind, w word, raw string) Statement {
+	name, value, ok := w.splitAssignment()
+	if !ok || !variableRegex.MatchString(name) {
+		return plain(raw)
+	}
+
+	s, ok := value.doubleQuotable()
+	if !ok {
+		return plain(raw)
+	}
+
+	return Statement{Kind: kind, Key: name, Value: s, Raw: raw}
+}
+
+// functionBody returns the body of a function from the text starting at its opening brace.
+// It returns false if the text is not exactly one brace group.
+func functionBody(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "{") {
+		return "", false
+	}
+
+	end := scan(s).firstGroupEnd
+	if end != len(s)-1 {
+		return "", false
+	}
+
+	body := s[1:end]
+	if strings.TrimSpace(body) == "" {
+		return "", false
+	}
+
+	// -- one-liners: "{ echo hello; }"
+	if !strings.Contains(body, "\n") {
+		return strings.TrimSpace(body), true
+	}
+
+	// -- drop the blank lines surrounding the body, preserving indentation.
+	lines := strings.Split(body, "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return strings.Join(lines, "\n"), true
+}
+
+func plain(raw string) Statement {
+	return Statement{Kind: PlainStatement, Raw: raw}
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+// Scanner
+//----------------------------------------------------------------------------------------------------------------------
+
+// scanState is the state of a shell text after it has been scanned.
+type scanState struct {
+	quote        byte
+	braces       int
+	parens       int
+	compounds    int
+	continuation bool
+	// firstGroupEnd is the index of the brace closing the first brace group, or -1.
+	firstGroupEnd int
+}
+
+// complete returns true if the scanned text is a complete statement.
+func (s scanState) complete() bool {
+	return s.quote == 0 && s.braces <= 0 && s.parens <= 0 && s.compounds <= 0 && !s.continuation
+}
+
+var (
+	compoundOpeners = map[string]struct{}{"if": {}, "case": {}, "for": {}, "while": {}, "until": {}, "select": {}}
+	compoundClosers = map[string]struct{}{"fi": {}, "esac": {}, "done": {}}
+	// commandPrefixes are reserved words after which a new command starts.
+	commandPrefixes = map[string]struct{}{"then": {}, "do": {}, "else": {}, "elif": {}, "if": {}, "while": {}, "until": {}, "!": {}}
+)
+
+// scan scans a shell text, tracking quotes, braces, parenthesis and compound commands.
+func scan(s string) scanState {
+	st := scanState{firstGroupEnd: -1}
+	commandPosition := true
+	groupStarted := false
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch st.quote {
+		case '\'':
+			if c == '\'' {
+				st.quote = 0
+			}
+			continue
+		case '"', '`':
+			if c == '\\' {
+				i++
+			} else if c == st.quote {
+				st.quote = 0
+			}
+			continue
+		}
+
+		switch {
+		case c == '\\':
+			if i == len(s)-1 {
+				st.continuation = true
+			}
+			i++
+			commandPosition = false
+		case c == '\'' || c == '"' || c == '`':
+			st.quote = c
+			commandPosition = false
+		case c == '#' && (i == 0 || isBlank(s[i-1])):
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+			commandPosition = true
+		case c == '{':
+			st.braces++
+			groupStarted = true
+			commandPosition = true
+		case c == '}':
+			st.braces--
+			if groupStarted && st.braces == 0 && st.firstGroupEnd < 0 {
+				st.firstGroupEnd = i
+			}
+			commandPosition = false
+		case c == '(':
+			st.parens++
+			commandPosition = true
+		case c == ')':
+			st.parens--
+			commandPosition = false
+		case c == ';' || c == '&' || c == '|' || c == '\n':
+			commandPosition = true
+		case isBlank(c):
+		default:
+			j := i
+			for j < len(s) && !isBlank(s[j]) && !strings.ContainsRune(";&|(){}'\"`\\\n", rune(s[j])) {
+				j++
+			}
+
+			w := s[i:j]
+			if commandPosition {
+				if _, ok := compoundOpeners[w]; ok {
+					st.compounds++
+				} else if _, ok := compoundClosers[w]; ok {
+					st.compounds--
+				}
+			}
+
+			_, isPrefix := commandPrefixes[w]
+			commandPosition = isPrefix
+			i = j - 1
+		}
+	}
+
+	return st
+}
+
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+//----------------------------------------------------------------------------------------------------------------------
+// Words
+//----------------------------------------------------------------------------------------------------------------------
+
+// segmentKind is the quoting of a segment of a word.
+type segmentKind int
+
+const (
+	unquotedSegment segmentKind = iota
+	singleQuotedSegment
+	doubleQuotedSegment
+)
+
+// segment is a part of a word sharing the same quoting.
+type segment struct {
+	kind    segmentKind
+	content string
+}
+
+// word is a shell word made of segments, e.g. `KEY="$HOME"'/bin'`.
+type word []segment
+
+// raw returns the content of the word if it is made of a single unquoted segment.
+func (w word) raw() string {
+	if len(w) != 1 || w[0].kind != unquotedSegment {
+		return ""
+	}
+	return w[0].content
+}
+
+// splitAssignment splits a "name=value" word.
+func (w word) splitAssignment() (string, word, bool) {
+	if len(w) == 0 || w[0].kind != unquotedSegment {
+		return "", nil, false
+	}
+
+	name, value, ok := strings.Cut(w[0].content, "=")
+	if !ok || name == "" {
+		return "", nil, false
+	}
+
+	out := make(word, 0, len(w))
+	if value != "" {
+		out = append(out, segment{kind: unquotedSegment, content: value})
+	}
+
+	return name, append(out, w[1:]...), true
+}
+
+// doubleQuotable returns the value of the word if it keeps the same meaning once rendered between
+// double quotes.
+func (w word) doubleQuotable() (string, bool) {
+	buf := ""
+	for _, seg := range w {
+		var forbidden string
+		switch seg.kind {
+		case unquotedSegment:
+			forbidden = "\\\"'~"
+		case singleQuotedSegment:
+			forbidden = "\\\"$`"
+		case doubleQuotedSegment:
+			forbidden = "\\\""
+		}
+
+		if strings.ContainsAny(seg.content, forbidden) || !isPrintable(seg.content) {
+			return "", false
+		}
+
+		buf += seg.content
+	}
+
+	return buf, true
+}
+
+// singleQuotable returns the value of the word if it keeps the same meaning once rendered between
+// single quotes.
+func (w word) singleQuotable() (string, bool) {
+	buf := ""
+	for _, seg := range w {
+		var forbidden string
+		switch seg.kind {
+		case unquotedSegment:
+			forbidden = "\\'$`~"
+		case singleQuotedSegment:
+			forbidden = ""
+		case doubleQuotedSegment:
+			forbidden = "\\'$`"
+		}
+
+		if strings.ContainsAny(seg.content, forbidden) || !isPrintable(seg.content) {
+			return "", false
+		}
+
+		buf += seg.content
+	}
+
+	return buf, true
+}
+
+// splitWords splits a simple command into words. It returns false if the command contains
+// operators, redirections or comments.
+func splitWords(s string) ([]word, bool) {
+	out := make([]word, 0)
+	var current word
+
+	flush := func() {
+		if current != nil {
+			out = append(out, current)
+			current = nil
+		}
+	}
+
+	appendUnquoted := func(str string) {
+		if len(current) > 0 && current[len(current)-1].kind == unquotedSegment {
+			current[len(current)-1].content += str
+			return
+		}
+		current = append(current, segment{kind: unquotedSegment, content: str})
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case isBlank(c):
+			flush()
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, false
+			}
+			current = append(current, segment{kind: singleQuotedSegment, content: s[i+1 : i+1+end]})
+			i += end + 1
+		case c == '"':
+			end := strings.IndexByte(s[i+1:], '"')
+			if end < 0 {
+				return nil, false
+			}
+			current = append(current, segment{kind: doubleQuotedSegment, content: s[i+1 : i+1+end]})
+			i += end + 1
+		case c == '$' && i+1 < len(s) && s[i+1] == '(':
+			end := closingParen(s, i+1)
+			if end < 0 {
+				return nil, false
+			}
+			appendUnquoted(s[i : end+1])
+			i = end
+		case c == '$' && i+1 < len(s) && s[i+1] == '{':
+			end := strings.IndexByte(s[i+1:], '}')
+			if end < 0 {
+				return nil, false
+			}
+			appendUnquoted(s[i : i+end+2])
+			i += end + 1
+		case c == '`':
+			end := strings.IndexByte(s[i+1:], '`')
+			if end < 0 {
+				return nil, false
+			}
+			appendUnquoted(s[i : i+end+2])
+			i += end + 1
+		case c == '#' && current == nil:
+			return nil, false
+		case strings.ContainsRune(";&|<>(){}\\", rune(c)):
+			return nil, false
+		default:
+			appendUnquoted(string(c))
+		}
+	}
+
+	flush()
+
+	return out, true
+}
+
+// closingParen returns the index of the parenthesis closing the one at index open.
+func closingParen(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isPrintable(s string) bool {
+	for _, r := range s {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
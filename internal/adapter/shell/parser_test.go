@@ -0,0 +1,129 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shelladapter_test
+
+import (
+	"bytes"
+	"testing"
+
+	shelladapter "github.com/alexandremahdhaoui/vib/internal/adapter/shell"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		Name  string
+		Input string
+		Want  []shelladapter.Statement
+	}{
+		{
+			Name:  "Alias",
+			Input: `alias ll='ls -laF'`,
+			Want: []shelladapter.Statement{
+				{Kind: shelladapter.AliasStatement, Key: "ll", Value: "ls -laF", Raw: `alias ll='ls -laF'`},
+			},
+		},
+
+		{
+			Name:  "AliasWithExpansionIsPlain",
+			Input: `alias here="cd $PWD"`,
+			Want: []shelladapter.Statement{
+				{Kind: shelladapter.PlainStatement, Raw: `alias here="cd $PWD"`},
+			},
+		},
+
+		{
+			Name:  "Export",
+			Input: `export GOPATH="$(go env GOPATH)"`,
+			Want: []shelladapter.Statement{
+				{
+					Kind:  shelladapter.ExportStatement,
+					Key:   "GOPATH",
+					Value: "$(go env GOPATH)",
+					Raw:   `export GOPATH="$(go env GOPATH)"`,
+				},
+			},
+		},
+
+		{
+			Name:  "Assignment",
+			Input: `PATH=${PATH}:${GOBIN}`,
+			Want: []shelladapter.Statement{
+				{Kind: shelladapter.AssignmentStatement, Key: "PATH", Value: "${PATH}:${GOBIN}", Raw: `PATH=${PATH}:${GOBIN}`},
+			},
+		},
+
+		{
+			Name:  "SingleQuotedExpansionIsPlain",
+			Input: `PS1='$ '`,
+			Want: []shelladapter.Statement{
+				{Kind: shelladapter.PlainStatement, Raw: `PS1='$ '`},
+			},
+		},
+
+		{
+			Name:  "Function",
+			Input: "greet() {\n  echo \"hello $1\"\n}",
+			Want: []shelladapter.Statement{
+				{
+					Kind:  shelladapter.FunctionStatement,
+					Key:   "greet",
+					Value: "  echo \"hello $1\"",
+					Raw:   "greet() {\n  echo \"hello $1\"\n}",
+				},
+			},
+		},
+
+		{
+			Name:  "FunctionKeywordOneLiner",
+			Input: "function k { kubectl \"$@\"; }",
+			Want: []shelladapter.Statement{
+				{
+					Kind:  shelladapter.FunctionStatement,
+					Key:   "k",
+					Value: "kubectl \"$@\";",
+					Raw:   "function k { kubectl \"$@\"; }",
+				},
+			},
+		},
+
+		{
+			Name:  "CompoundCommandAndComment",
+			Input: "# comment\nif [ -f ~/.local ]; then\n  export A=b\nfi\n\nalias g=git",
+			Want: []shelladapter.Statement{
+				{Kind: shelladapter.PlainStatement, Raw: "# comment"},
+				{Kind: shelladapter.PlainStatement, Raw: "if [ -f ~/.local ]; then\n  export A=b\nfi"},
+				{Kind: shelladapter.AliasStatement, Key: "g", Value: "git", Raw: "alias g=git"},
+			},
+		},
+
+		{
+			Name:  "Heredoc",
+			Input: "cat <<EOF > /tmp/x\n}\nEOF\nsource ~/.env",
+			Want: []shelladapter.Statement{
+				{Kind: shelladapter.PlainStatement, Raw: "cat <<EOF > /tmp/x\n}\nEOF"},
+				{Kind: shelladapter.PlainStatement, Raw: "source ~/.env"},
+			},
+		},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			got, err := shelladapter.Parse(bytes.NewBufferString(tc.Input))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Want, got)
+		})
+	}
+}
@@ -21,7 +21,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
@@ -32,11 +33,15 @@ import (
 // FilesystemStorage
 //----------------------------------------------------------------------------------------------------------------------
 
-// NewFilesystem instantiate a new strategy
+// NewFilesystem instantiate a new strategy.
+// The filesystem keeps the "historyLimit" previous versions of each resource; history is disabled
+// if historyLimit is 0.
 func NewFilesystem(
 	apiServer types.APIServer,
 	codec types.Codec,
+	decoder types.DynamicDecoder[types.APIVersionKind],
 	resourceDir string,
+	historyLimit int,
 ) (types.Storage, error) {
 	// ensure the resourceDir exists
 	defaultNSDir := filepath.Join(resourceDir, types.DefaultNamespace)
@@ -45,22 +50,36 @@ func NewFilesystem(
 	}
 
 	return &filesystem{
-		resourceDir: resourceDir,
-		codec:       codec,
-		apiServer:   apiServer,
+		resourceDir:  resourceDir,
+		codec:        codec,
+		decoder:      decoder,
+		apiServer:    apiServer,
+		historyLimit: historyLimit,
 	}, nil
 }
 
 // filesystem operates T through the filesystem.
 // Resources are stored on the filesystem using the following convention:
 // - Filename: {{ T.APIVersion() }}.{{ T.Kind() }}.{{ T.IMetadata().Name }}. {{ s.encoder.Encoding() }}
+//
+// Writes are serialized across processes by an advisory lock held on the namespace directory's
+// lock file.
 type filesystem struct {
-	resourceDir string
-	codec       types.Codec
-	apiServer   types.APIServer
+	resourceDir  string
+	codec        types.Codec
+	decoder      types.DynamicDecoder[types.APIVersionKind]
+	apiServer    types.APIServer
+	historyLimit int
 }
 
-var errAPIVersionMustBeSpecified = errors.New("apiVersion must be specified")
+var (
+	_ types.Storage          = &filesystem{}
+	_ types.HistoryStorage   = &filesystem{}
+	_ types.EncodingMigrator = &filesystem{}
+	_ types.NamespaceLister  = &filesystem{}
+
+	errAPIVersionMustBeSpecified = errors.New("apiVersion must be specified")
+)
 
 func (fs *filesystem) List(
 	avk types.APIVersionKind,
@@ -84,26 +103,32 @@ func (fs *filesystem) List(
 		return nil, err
 	}
 
-	r := strings.ToLower(fmt.Sprintf(
-		`%s\.%s\..*\.%s`,
-		cleanAPIVersionForFilesystem(avk.APIVersion()),
-		avk.Kind(),
-		fs.codec.Encoding(),
-	))
-
-	regex, err := regexp.Compile(r)
-	if err != nil {
-		return nil, err
-	}
-
-	for _, dentry := range dentries {
-		filename := dentry.Name()
-		if dentry.IsDir() || !regex.MatchString(filename) {
-			continue
+	// -- resources may be stored with any supported encoding and any version of their kind. The
+	// preferred version is listed first.
+	basenames := make([]string, 0)
+	names := make([]string, 0)
+	for _, apiVersion := range fs.versions(avk) {
+		prefix := fs.basenameWithoutExt(types.NewAPIVersionKind(apiVersion, avk.Kind()), "")
+
+		for _, dentry := range dentries {
+			filename := dentry.Name()
+			if dentry.IsDir() || !strings.HasPrefix(filename, prefix) {
+				continue
+			}
+
+			basename, _, ok := splitEncoding(filename)
+			name := strings.TrimPrefix(basename, prefix)
+			if !ok || slices.Contains(names, name) {
+				continue
+			}
+
+			basenames = append(basenames, basename)
+			names = append(names, name)
 		}
+	}
 
-		resourceAbsPath := filepath.Join(namespaceAbsPath, filename)
-		v, err := fs.read(resourceAbsPath)
+	for _, basename := range basenames {
+		v, err := fs.read(fs.findAbsPath(filepath.Join(namespaceAbsPath, basename)))
 		if err != nil {
 			return nil, err
 		}
@@ -129,7 +154,7 @@ func (fs *filesystem) Get(
 		return types.Resource[types.APIVersionKind]{}, err
 	}
 
-	v, err := fs.read(fs.computeResourceAbsPath(avk, nsName, false))
+	v, err := fs.read(fs.findResourceAbsPath(avk, nsName))
 	if os.IsNotExist(err) {
 		return types.Resource[types.APIVersionKind]{}, flaterrors.Join(err, types.ErrNotFound)
 	} else if err != nil {
@@ -139,17 +164,38 @@ func (fs *filesystem) Get(
 	return v, nil
 }
 
-// Create should create only if file does not already exist.
-func (fs *filesystem) Create(res types.Resource[types.APIVersionKind]) error {
-	if err := types.ValidateResource(res); err != nil {
-		return err
+// Namespaces implements the types.NamespaceLister interface.
+func (fs *filesystem) Namespaces() ([]string, error) {
+	dentries, err := os.ReadDir(fs.resourceDir)
+	if err != nil {
+		return nil, err
 	}
 
-	nsName := types.NamespacedName{
-		Name:      res.Metadata.Name,
-		Namespace: res.Metadata.Namespace,
+	out := make([]string, 0, len(dentries))
+	for _, dentry := range dentries {
+		// -- dot directories hold the locks and the history of the resources.
+		if !dentry.IsDir() || strings.HasPrefix(dentry.Name(), ".") {
+			continue
+		}
+
+		out = append(out, dentry.Name())
 	}
 
+	return out, nil
+}
+
+// Create should create only if file does not already exist. Existing resources are rejected before
+// the resource is admitted, so that admission hooks do not run for resources that already exist.
+// The resourceVersion of deleted resources created again continues from their history.
+func (fs *filesystem) Create(res types.Resource[types.APIVersionKind]) error {
+	nsName := types.NewNamespacedNameFromMetadata(res.Metadata)
+
+	unlock, err := fs.lockNamespace(nsName.Namespace)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	exist, err := resourceExist(fs, res.Spec, nsName)
 	if err != nil {
 		return err
@@ -168,11 +214,73 @@ func (fs *filesystem) Create(res types.Resource[types.APIVersionKind]) error {
 		)
 	}
 
+	if err := fs.apiServer.Admit(types.CreateOperation, &res); err != nil {
+		return err
+	}
+
+	// -- resources that were deleted, e.g. restored resources, continue from their latest revision, so
+	// that stale resourceVersions are still rejected.
+	latest, err := fs.latestResourceVersion(res.Spec, nsName)
+	if err != nil {
+		return err
+	}
+
+	res.Metadata.ResourceVersion = nextResourceVersion(latest)
+
 	return fs.writeAtomic(res)
 }
 
+// Update rejects stale resources: if the provided resource specifies a resourceVersion, it must match
+// the resourceVersion of the stored resource. Missing and stale resources are rejected before the
+// resource is admitted, so that admission hooks only review resources that can be updated.
 func (fs *filesystem) Update(v types.Resource[types.APIVersionKind]) error {
-	if err := types.ValidateResource(v); err != nil {
+	if err := types.ValidateAPIVersion(v.APIVersion); err != nil {
+		return flaterrors.Join(err, errAPIVersionMustBeSpecified)
+	}
+
+	nsName := types.NewNamespacedNameFromMetadata(v.Metadata)
+	if err := types.ValidateNamespacedName(nsName); err != nil {
+		return err
+	}
+
+	if err := fs.checkNamespaceExist(nsName.Namespace); err != nil {
+		return err
+	}
+
+	unlock, err := fs.lockNamespace(nsName.Namespace)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, err := fs.Get(types.NewAVKFromResource(v), nsName)
+	if err != nil {
+		return err
+	}
+
+	if v.Metadata.ResourceVersion != "" &&
+		v.Metadata.ResourceVersion != current.Metadata.ResourceVersion {
+		return flaterrors.Join(
+			types.ErrConflict,
+			fmt.Errorf(
+				"apiVersion: %q, kind: %q, name: %q, resourceVersion: %q, storedResourceVersion: %q",
+				v.APIVersion,
+				v.Kind,
+				v.Metadata.Name,
+				v.Metadata.ResourceVersion,
+				current.Metadata.ResourceVersion,
+			),
+			errors.New("cannot update resource"),
+		)
+	}
+
+	if err := fs.apiServer.Admit(types.UpdateOperation, &v); err != nil {
+		return err
+	}
+
+	v.Metadata.ResourceVersion = nextResourceVersion(current.Metadata.ResourceVersion)
+
+	if err := fs.archive(v.Spec, nsName); err != nil {
 		return err
 	}
 
@@ -192,17 +300,90 @@ func (fs *filesystem) Delete(avk types.APIVersionKind, nsName types.NamespacedNa
 		return err
 	}
 
-	return os.Remove(fs.computeResourceAbsPath(avk, nsName, false))
+	if err := fs.checkNamespaceExist(nsName.Namespace); err != nil {
+		return err
+	}
+
+	unlock, err := fs.lockNamespace(nsName.Namespace)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, err := fs.Get(avk, nsName)
+	if err != nil {
+		return err
+	}
+
+	if err := fs.apiServer.Admit(types.DeleteOperation, &current); err != nil {
+		return err
+	}
+
+	if err := fs.archive(avk, nsName); err != nil {
+		return err
+	}
+
+	if err := os.Remove(fs.findResourceAbsPath(avk, nsName)); err != nil {
+		return err
+	}
+
+	if err := fs.removeOtherVersions(current.Spec, nsName); err != nil {
+		return err
+	}
+
+	return fs.removeOtherEncodings(fs.computeResourceAbsPath(avk, nsName))
 }
 
-func (fs *filesystem) writeAtomic(v types.Resource[types.APIVersionKind]) error {
-	nsName := types.NamespacedName{
-		Name:      v.Metadata.Name,
-		Namespace: v.Metadata.Namespace,
+// checkNamespaceExist returns types.ErrNotFound if the directory of the namespace does not exist.
+// Locking a namespace creates its directory: operations on existing resources check the namespace
+// first, so that they do not leave empty namespaces behind.
+func (fs *filesystem) checkNamespaceExist(namespace string) error {
+	_, err := os.Stat(fs.computeNamespaceAbsPath(namespace))
+	if os.IsNotExist(err) {
+		return flaterrors.Join(err, types.ErrNotFound, fmt.Errorf("namespace %q does not exist", namespace))
 	}
 
-	tmpDest := fs.computeResourceAbsPath(v.Spec, nsName, true)
-	dest := fs.computeResourceAbsPath(v.Spec, nsName, false)
+	return err
+}
+
+// lockNamespace acquires the advisory lock of the namespace directory and removes temporary files
+// left behind by crashed writers. The directory of the namespace is created if it does not exist.
+// The returned function releases the lock.
+func (fs *filesystem) lockNamespace(namespace string) (func(), error) {
+	nsDir := fs.computeNamespaceAbsPath(namespace)
+	if err := os.MkdirAll(nsDir, 0777); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(filepath.Join(nsDir, lockFilename), os.O_CREATE|os.O_RDWR, 0640)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := flock(f); err != nil {
+		_ = f.Close()
+		return nil, flaterrors.Join(err, fmt.Errorf("cannot lock namespace %q", namespace))
+	}
+
+	unlock := func() {
+		_ = funlock(f)
+		_ = f.Close()
+	}
+
+	// -- All writers hold the lock while a temporary file exists: remaining temporary files are stale.
+	if err := removeTmpFiles(nsDir); err != nil {
+		unlock()
+		return nil, err
+	}
+
+	return unlock, nil
+}
+
+// writeAtomic writes the resource to a temporary file and renames it to its destination.
+// The caller must hold the namespace lock.
+func (fs *filesystem) writeAtomic(v types.Resource[types.APIVersionKind]) error {
+	nsName := types.NewNamespacedNameFromMetadata(v.Metadata)
+	dest := fs.computeResourceAbsPath(v.Spec, nsName)
 
 	b, err := fs.codec.Marshal(v)
 	if err != nil {
@@ -215,61 +396,72 @@ func (fs *filesystem) writeAtomic(v types.Resource[types.APIVersionKind]) error
 		return err
 	}
 
-	if err = os.WriteFile(tmpDest, b, 0640); err != nil {
+	tmp, err := os.CreateTemp(destDir, fmt.Sprintf("%s%s.*", tmpFilePrefix, filepath.Base(dest)))
+	if err != nil {
 		return err
 	}
+	defer os.Remove(tmp.Name()) //nolint: errcheck // no-op once renamed
 
-	if err := os.Rename(tmpDest, dest); err != nil {
+	if err := writeAndSync(tmp, b); err != nil {
 		return err
 	}
 
-	return nil
-}
-
-// read tries to read file corresponding to the specified object's name.
-// It returns os.ErrNotExist if file does not exist.
-func (fs *filesystem) read(path string) (types.Resource[types.APIVersionKind], error) {
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return types.Resource[types.APIVersionKind]{}, err
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return err
 	}
 
-	b, err := os.ReadFile(path)
-	if err != nil {
-		return types.Resource[types.APIVersionKind]{}, err
+	// -- the resource may have been stored with another encoding or another version.
+	if err := fs.removeOtherEncodings(dest); err != nil {
+		return err
 	}
 
-	raw := new(types.Resource[any])
-	if err := fs.codec.Unmarshal(b, raw); err != nil {
-		return types.Resource[types.APIVersionKind]{}, err
+	if err := fs.removeOtherVersions(v.Spec, nsName); err != nil {
+		return err
 	}
 
-	out, err := fs.apiServer.Get(types.NewAPIVersionKind(raw.APIVersion, raw.Kind))
+	syncDir(destDir)
+
+	return nil
+}
+
+// read tries to read file corresponding to the specified object's name.
+// It returns os.ErrNotExist if file does not exist.
+// The file is strictly decoded: unknown fields and type mismatches are reported as
+// *types.DecodeError.
+func (fs *filesystem) read(path string) (types.Resource[types.APIVersionKind], error) {
+	f, err := os.Open(path)
 	if err != nil {
 		return types.Resource[types.APIVersionKind]{}, err
 	}
+	defer f.Close() //nolint: errcheck
 
-	out.Metadata = raw.Metadata // NOTE: metadata must be copied
-
-	b, err = fs.codec.Marshal(raw.Spec)
+	list, err := fs.decoder.Decode(f)
 	if err != nil {
 		return types.Resource[types.APIVersionKind]{}, err
 	}
 
-	if err = fs.codec.Unmarshal(b, &out.Spec); err != nil {
-		return types.Resource[types.APIVersionKind]{}, err
+	if len(list) != 1 {
+		return types.Resource[types.APIVersionKind]{}, flaterrors.Join(
+			fmt.Errorf("expected 1 resource in %q, got %d", path, len(list)),
+			types.ErrVal,
+		)
 	}
 
-	return out, nil
+	return list[0], nil
 }
 
 // filepathByNamespacedName computes the resource filename, based on the naming convention
 func (fs *filesystem) basename(avk types.APIVersionKind, resourceName string) string {
+	return fmt.Sprintf("%s.%s", fs.basenameWithoutExt(avk, resourceName), fs.codec.Encoding())
+}
+
+// basenameWithoutExt computes the resource filename without its encoding extension.
+func (fs *filesystem) basenameWithoutExt(avk types.APIVersionKind, resourceName string) string {
 	return strings.ToLower(fmt.Sprintf(
-		"%s.%s.%s.%s",
+		"%s.%s.%s",
 		cleanAPIVersionForFilesystem(avk.APIVersion()),
 		avk.Kind(),
 		resourceName,
-		fs.codec.Encoding(),
 	))
 }
 
@@ -283,26 +475,193 @@ func (fs *filesystem) computeNamespaceAbsPath(
 	return filepath.Join(fs.resourceDir, namespace)
 }
 
-// computeResourceAbsPath joins the resourceDir to the basename
+// computeResourceAbsPath joins the resourceDir to the basename. The path uses the encoding of the
+// storage.
 func (fs *filesystem) computeResourceAbsPath(
 	avk types.APIVersionKind,
 	nsName types.NamespacedName,
-	isTmp bool,
 ) string {
 	basename := fs.basename(avk, nsName.Name)
-	if isTmp {
-		basename = fmt.Sprintf(".tmp.%s", basename)
-	}
 	return filepath.Join(
 		fs.computeNamespaceAbsPath(nsName.Namespace),
 		basename,
 	)
 }
 
+// findResourceAbsPath returns the path to the file storing a resource. Resources may be stored
+// with any supported encoding and any version of their kind; the encoding of the storage and the
+// preferred version are preferred.
+// It returns the path computed by computeResourceAbsPath if the resource does not exist.
+func (fs *filesystem) findResourceAbsPath(
+	avk types.APIVersionKind,
+	nsName types.NamespacedName,
+) string {
+	for _, apiVersion := range fs.versions(avk) {
+		path := fs.findAbsPath(filepath.Join(
+			fs.computeNamespaceAbsPath(nsName.Namespace),
+			fs.basenameWithoutExt(types.NewAPIVersionKind(apiVersion, avk.Kind()), nsName.Name),
+		))
+
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+
+	return fs.computeResourceAbsPath(avk, nsName)
+}
+
+// versions returns the APIVersions a resource of avk may be stored with, starting with the
+// preferred version of its kind.
+func (fs *filesystem) versions(avk types.APIVersionKind) []types.APIVersion {
+	versions := fs.apiServer.Versions(avk.Kind())
+	if !slices.ContainsFunc(versions, func(v types.APIVersion) bool {
+		return strings.EqualFold(v, avk.APIVersion())
+	}) {
+		return []types.APIVersion{avk.APIVersion()}
+	}
+
+	return versions
+}
+
+// findAbsPath returns the path of the existing file named pathWithoutExt with a supported encoding
+// extension. The encoding of the storage is preferred.
+// It returns the path with the encoding of the storage if no file exists.
+func (fs *filesystem) findAbsPath(pathWithoutExt string) string {
+	preferred := fmt.Sprintf("%s.%s", pathWithoutExt, fs.codec.Encoding())
+	if _, err := os.Stat(preferred); err == nil {
+		return preferred
+	}
+
+	for _, encoding := range types.Encodings {
+		path := fmt.Sprintf("%s.%s", pathWithoutExt, encoding)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+
+	return preferred
+}
+
+// removeOtherEncodings removes the files storing the same resource as path with another encoding.
+func (fs *filesystem) removeOtherEncodings(path string) error {
+	pathWithoutExt, encoding, ok := splitEncoding(path)
+	if !ok {
+		return nil
+	}
+
+	for _, other := range types.Encodings {
+		if other == encoding {
+			continue
+		}
+
+		err := os.Remove(fmt.Sprintf("%s.%s", pathWithoutExt, other))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeOtherVersions removes the files storing the resource with another version of its kind, in
+// any encoding.
+func (fs *filesystem) removeOtherVersions(avk types.APIVersionKind, nsName types.NamespacedName) error {
+	for _, apiVersion := range fs.versions(avk) {
+		if strings.EqualFold(apiVersion, avk.APIVersion()) {
+			continue
+		}
+
+		pathWithoutExt := filepath.Join(
+			fs.computeNamespaceAbsPath(nsName.Namespace),
+			fs.basenameWithoutExt(types.NewAPIVersionKind(apiVersion, avk.Kind()), nsName.Name),
+		)
+
+		for _, encoding := range types.Encodings {
+			err := os.Remove(fmt.Sprintf("%s.%s", pathWithoutExt, encoding))
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 //----------------------------------------------------------------------------------------------------------------------
 // Storage Utils
 //----------------------------------------------------------------------------------------------------------------------
 
+const (
+	// lockFilename is the name of the file used to lock a namespace directory.
+	lockFilename = ".lock"
+	// tmpFilePrefix prefixes the temporary files written by writeAtomic.
+	tmpFilePrefix = ".tmp."
+	// historyDirname is the name of the directory storing the revisions of the resources of a
+	// namespace.
+	historyDirname = ".history"
+)
+
+// writeAndSync writes b to f, flushes it to disk and closes f.
+func writeAndSync(f *os.File, b []byte) error {
+	if _, err := f.Write(b); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	if err := f.Chmod(0640); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// syncDir flushes the directory entries of dir to disk, ensuring a rename is persisted.
+// Syncing a directory is not supported on every platform, thus errors are ignored.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+
+	_ = d.Sync()
+	_ = d.Close()
+}
+
+// removeTmpFiles removes the temporary files of dir.
+func removeTmpFiles(dir string) error {
+	matches, err := filepath.Glob(filepath.Join(dir, tmpFilePrefix+"*"))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range matches {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// splitEncoding splits a filename into its name and its encoding extension. It returns false if
+// the extension is not a supported encoding.
+func splitEncoding(filename string) (string, types.Encoding, bool) {
+	ext := filepath.Ext(filename)
+	encoding := types.Encoding(strings.TrimPrefix(ext, "."))
+
+	if !slices.Contains(types.Encodings, encoding) {
+		return "", "", false
+	}
+
+	return strings.TrimSuffix(filename, ext), encoding, true
+}
+
 // resourceExist checks if a named resource already exist
 func resourceExist(
 	storage types.Storage,
@@ -319,6 +678,17 @@ func resourceExist(
 	return true, nil
 }
 
+// nextResourceVersion returns the resourceVersion following the provided one.
+// ResourceVersions are monotonic counters; resources written before resourceVersions were introduced
+// do not have one and are considered to be at version 0.
+func nextResourceVersion(current string) string {
+	n, err := strconv.ParseUint(current, 10, 64)
+	if err != nil {
+		n = 0
+	}
+	return strconv.FormatUint(n+1, 10)
+}
+
 // cleanAPIVersionForFilesystem transforms `vib/v1alpha1` into `vib_v1alpha1`
 func cleanAPIVersionForFilesystem(s types.APIVersion) string {
 	return strings.ReplaceAll(string(s), "/", "_")
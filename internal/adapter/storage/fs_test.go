@@ -0,0 +1,440 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storageadapter_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	codecadapter "github.com/alexandremahdhaoui/vib/internal/adapter/codec"
+	storageadapter "github.com/alexandremahdhaoui/vib/internal/adapter/storage"
+	"github.com/alexandremahdhaoui/vib/internal/service"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+	"github.com/alexandremahdhaoui/vib/pkg/apis/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilesystem(t *testing.T) {
+	var (
+		apiServer   types.APIServer
+		storage     types.Storage
+		resourceDir string
+	)
+
+	setup := func(t *testing.T) {
+		t.Helper()
+
+		apiServer = service.NewAPIServer()
+		v1alpha1.RegisterWithManager(apiServer)
+
+		var err error
+		resourceDir = t.TempDir()
+		storage, err = storageadapter.NewFilesystem(
+			apiServer,
+			codecadapter.NewYAML(),
+			codecadapter.NewDynamicResourceDecoder(apiServer),
+			resourceDir,
+			2,
+		)
+		assert.NoError(t, err)
+	}
+
+	newProfile := func(name string) types.Resource[types.APIVersionKind] {
+		return types.Resource[types.APIVersionKind]{
+			APIVersion: v1alpha1.APIVersion,
+			Kind:       v1alpha1.ProfileKind,
+			Metadata:   types.Metadata{Name: name, Namespace: types.DefaultNamespace},
+			Spec:       &v1alpha1.ProfileSpec{},
+		}
+	}
+
+	nsName := types.NamespacedName{Name: "test", Namespace: types.DefaultNamespace}
+
+	t.Run("ResourceVersion", func(t *testing.T) {
+		setup(t)
+
+		assert.NoError(t, storage.Create(newProfile("test")))
+
+		res, err := storage.Get(&v1alpha1.ProfileSpec{}, nsName)
+		assert.NoError(t, err)
+		assert.Equal(t, "1", res.Metadata.ResourceVersion)
+
+		assert.NoError(t, storage.Update(res))
+
+		res, err = storage.Get(&v1alpha1.ProfileSpec{}, nsName)
+		assert.NoError(t, err)
+		assert.Equal(t, "2", res.Metadata.ResourceVersion)
+	})
+
+	t.Run("UpdateConflict", func(t *testing.T) {
+		setup(t)
+
+		assert.NoError(t, storage.Create(newProfile("test")))
+
+		stale, err := storage.Get(&v1alpha1.ProfileSpec{}, nsName)
+		assert.NoError(t, err)
+
+		fresh, err := storage.Get(&v1alpha1.ProfileSpec{}, nsName)
+		assert.NoError(t, err)
+		assert.NoError(t, storage.Update(fresh))
+
+		assert.ErrorIs(t, storage.Update(stale), types.ErrConflict)
+
+		// -- resources without resourceVersion are updated unconditionally.
+		assert.NoError(t, storage.Update(newProfile("test")))
+	})
+
+	t.Run("UpdateNotFound", func(t *testing.T) {
+		setup(t)
+
+		assert.ErrorIs(t, storage.Update(newProfile("test")), types.ErrNotFound)
+	})
+
+	t.Run("MissingNamespace", func(t *testing.T) {
+		setup(t)
+
+		res := newProfile("test")
+		res.Metadata.Namespace = "missing"
+
+		assert.ErrorIs(t, storage.Update(res), types.ErrNotFound)
+		assert.ErrorIs(t, storage.Delete(res.Spec, types.NewNamespacedNameFromMetadata(res.Metadata)), types.ErrNotFound)
+
+		// -- the namespace is not created by the failed operations.
+		namespaces, err := storage.(types.NamespaceLister).Namespaces()
+		assert.NoError(t, err)
+		assert.NotContains(t, namespaces, "missing")
+	})
+
+	t.Run("ConcurrentCreate", func(t *testing.T) {
+		setup(t)
+
+		var (
+			wg      sync.WaitGroup
+			mu      sync.Mutex
+			created int
+		)
+
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				err := storage.Create(newProfile("test"))
+				if errors.Is(err, types.ErrExists) {
+					return
+				}
+				assert.NoError(t, err)
+
+				mu.Lock()
+				created++
+				mu.Unlock()
+			}()
+		}
+
+		wg.Wait()
+		assert.Equal(t, 1, created)
+	})
+
+	t.Run("RemoveStaleTmpFiles", func(t *testing.T) {
+		setup(t)
+
+		stale := filepath.Join(resourceDir, types.DefaultNamespace, ".tmp.stale.yaml.123")
+		assert.NoError(t, os.WriteFile(stale, []byte("stale"), 0640))
+
+		assert.NoError(t, storage.Create(newProfile("test")))
+
+		_, err := os.Stat(stale)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("History", func(t *testing.T) {
+		setup(t)
+
+		historyStorage, ok := storage.(types.HistoryStorage)
+		assert.True(t, ok)
+
+		res := newProfile("test")
+		assert.NoError(t, storage.Create(res))
+
+		for _, ref := range []string{"ref-a", "ref-b", "ref-c"} {
+			res.Spec = &v1alpha1.ProfileSpec{Refs: []types.NamespacedName{{Name: ref}}}
+			assert.NoError(t, storage.Update(res))
+		}
+
+		// -- history is limited to 2 revisions.
+		revisions, err := historyStorage.History(&v1alpha1.ProfileSpec{}, nsName)
+		assert.NoError(t, err)
+		assert.Len(t, revisions, 2)
+		assert.Equal(t, 2, revisions[0].Revision)
+		assert.Equal(t, "2", revisions[0].ResourceVersion)
+		assert.Equal(t, 3, revisions[1].Revision)
+		assert.Equal(t, "3", revisions[1].ResourceVersion)
+
+		revision, err := historyStorage.GetRevision(&v1alpha1.ProfileSpec{}, nsName, 3)
+		assert.NoError(t, err)
+		assert.Equal(t,
+			[]types.NamespacedName{{Name: "ref-b", Namespace: types.DefaultNamespace}},
+			revision.Spec.(*v1alpha1.ProfileSpec).Refs,
+		)
+
+		_, err = historyStorage.GetRevision(&v1alpha1.ProfileSpec{}, nsName, 1)
+		assert.ErrorIs(t, err, types.ErrNotFound)
+
+		// -- deleted resources are kept in the history.
+		assert.NoError(t, storage.Delete(&v1alpha1.ProfileSpec{}, nsName))
+
+		revisions, err = historyStorage.History(&v1alpha1.ProfileSpec{}, nsName)
+		assert.NoError(t, err)
+		assert.Len(t, revisions, 2)
+		assert.Equal(t, "4", revisions[1].ResourceVersion)
+
+		// -- the history is not listed as a resource.
+		list, err := storage.List(&v1alpha1.ProfileSpec{}, types.DefaultNamespace)
+		assert.NoError(t, err)
+		assert.Empty(t, list)
+
+		// -- restored resources continue from their latest revision: stale resourceVersions are rejected.
+		revision, err = historyStorage.GetRevision(&v1alpha1.ProfileSpec{}, nsName, 3)
+		assert.NoError(t, err)
+		assert.NoError(t, storage.Create(revision))
+
+		res, err = storage.Get(&v1alpha1.ProfileSpec{}, nsName)
+		assert.NoError(t, err)
+		assert.Equal(t, "5", res.Metadata.ResourceVersion)
+
+		res.Metadata.ResourceVersion = "1"
+		assert.ErrorIs(t, storage.Update(res), types.ErrConflict)
+	})
+
+	t.Run("MigrateEncoding", func(t *testing.T) {
+		setup(t)
+
+		assert.NoError(t, storage.Create(newProfile("test")))
+
+		apiServer := service.NewAPIServer()
+		v1alpha1.RegisterWithManager(apiServer)
+
+		jsonStorage, err := storageadapter.NewFilesystem(
+			apiServer,
+			codecadapter.NewJSON(),
+			codecadapter.NewDynamicResourceDecoder(apiServer),
+			resourceDir,
+			2,
+		)
+		assert.NoError(t, err)
+
+		// -- resources stored with another encoding are still readable.
+		list, err := jsonStorage.List(&v1alpha1.ProfileSpec{}, types.DefaultNamespace)
+		assert.NoError(t, err)
+		assert.Len(t, list, 1)
+
+		_, err = jsonStorage.Get(&v1alpha1.ProfileSpec{}, nsName)
+		assert.NoError(t, err)
+
+		migrator, ok := jsonStorage.(types.EncodingMigrator)
+		assert.True(t, ok)
+
+		migrations, err := migrator.MigrateEncoding()
+		assert.NoError(t, err)
+		assert.Equal(t, []types.Migration{{
+			Namespace: types.DefaultNamespace,
+			Filename:  "vib.amahdha.com_v1alpha1.profile.test.yaml",
+			From:      types.YAMLEncoding,
+			To:        types.JSONEncoding,
+		}}, migrations)
+
+		matches, err := filepath.Glob(filepath.Join(resourceDir, types.DefaultNamespace, "*.profile.test.*"))
+		assert.NoError(t, err)
+		assert.Len(t, matches, 1)
+		assert.Equal(t, ".json", filepath.Ext(matches[0]))
+
+		// -- migrating twice is a no-op.
+		migrations, err = migrator.MigrateEncoding()
+		assert.NoError(t, err)
+		assert.Empty(t, migrations)
+	})
+
+	t.Run("Conversion", func(t *testing.T) {
+		setup(t)
+
+		legacy := `apiVersion: vib.alexandre.mahdhaoui.com/v1alpha1
+kind: ExpressionSet
+metadata:
+  name: test
+  namespace: default
+spec:
+  resolverRef:
+    name: alias
+    namespace: vib-system
+  keyValues:
+    - g: git
+`
+
+		legacyPath := filepath.Join(
+			resourceDir,
+			types.DefaultNamespace,
+			"vib.alexandre.mahdhaoui.com_v1alpha1.expressionset.test.yaml",
+		)
+		assert.NoError(t, os.WriteFile(legacyPath, []byte(legacy), 0640))
+
+		// -- resources stored with a former version are read with the preferred version.
+		list, err := storage.List(&v1alpha1.ExpressionSetSpec{}, types.DefaultNamespace)
+		assert.NoError(t, err)
+		assert.Len(t, list, 1)
+
+		res, err := storage.Get(&v1alpha1.ExpressionSetSpec{}, nsName)
+		assert.NoError(t, err)
+		assert.Equal(t, v1alpha1.APIVersion, res.APIVersion)
+		assert.IsType(t, &v1alpha1.ExpressionSetSpec{}, res.Spec)
+
+		// -- resources are written with the preferred version.
+		assert.NoError(t, storage.Update(res))
+
+		_, err = os.Stat(legacyPath)
+		assert.True(t, os.IsNotExist(err))
+
+		_, err = os.Stat(filepath.Join(
+			resourceDir,
+			types.DefaultNamespace,
+			"vib.amahdha.com_v1alpha1.expressionset.test.yaml",
+		))
+		assert.NoError(t, err)
+	})
+
+	t.Run("OutputMatchesSchema", func(t *testing.T) {
+		setup(t)
+
+		resources := []types.Resource[types.APIVersionKind]{
+			newProfile("test"),
+			{
+				APIVersion: v1alpha1.APIVersion,
+				Kind:       v1alpha1.ExpressionSetKind,
+				Metadata:   types.Metadata{Name: "keys", Namespace: types.DefaultNamespace},
+				Spec: &v1alpha1.ExpressionSetSpec{
+					ArbitraryKeys: []string{"set -o vi"},
+					ResolverRef:   types.NamespacedName{Name: "plain", Namespace: types.VibSystemNamespace},
+				},
+			},
+			{
+				APIVersion: v1alpha1.APIVersion,
+				Kind:       v1alpha1.ExpressionSetKind,
+				Metadata:   types.Metadata{Name: "values", Namespace: types.DefaultNamespace},
+				Spec: &v1alpha1.ExpressionSetSpec{
+					KeyValues:   []map[string]string{{"g": "git"}},
+					ResolverRef: types.NamespacedName{Name: "alias", Namespace: types.VibSystemNamespace},
+				},
+			},
+		}
+
+		for _, res := range v1alpha1.DefaultAVKResolver() {
+			res.Metadata.Namespace = types.VibSystemNamespace
+			resources = append(resources, res)
+		}
+
+		// -- the resources printed by "vib get -o json" must be valid against "vib schema".
+		for _, res := range resources {
+			assert.NoError(t, storage.Create(res))
+
+			got, err := storage.Get(types.NewAVKFromResource(res), types.NewNamespacedNameFromMetadata(res.Metadata))
+			assert.NoError(t, err)
+
+			b, err := codecadapter.NewJSON().Marshal(got)
+			assert.NoError(t, err)
+
+			var v any
+			assert.NoError(t, json.Unmarshal(b, &v))
+
+			schema, err := apiServer.Schema(types.NewAVKFromResource(res))
+			assert.NoError(t, err)
+			assert.NoError(t, schema.Validate(v), string(b))
+		}
+	})
+
+	t.Run("AdmissionOfExistingResources", func(t *testing.T) {
+		setup(t)
+
+		for _, res := range v1alpha1.DefaultAVKResolver() {
+			res.Metadata.Namespace = types.VibSystemNamespace
+			assert.NoError(t, storage.Create(res))
+		}
+
+		hook := &denyAllHook{}
+		apiServer.RegisterAdmissionHooks([]types.AdmissionHook{hook})
+
+		// -- creating existing resources does not run the hooks, and a deny-all hook does not break reads.
+		for _, res := range v1alpha1.DefaultAVKResolver() {
+			res.Metadata.Namespace = types.VibSystemNamespace
+			assert.ErrorIs(t, storage.Create(res), types.ErrExists)
+
+			_, err := storage.Get(types.NewAVKFromResource(res), types.NewNamespacedNameFromMetadata(res.Metadata))
+			assert.NoError(t, err)
+		}
+
+		list, err := storage.List(&v1alpha1.ResolverSpec{}, types.VibSystemNamespace)
+		assert.NoError(t, err)
+		assert.Len(t, list, len(v1alpha1.DefaultAVKResolver()))
+		assert.Zero(t, hook.calls)
+
+		assert.ErrorIs(t, storage.Create(newProfile("test")), types.ErrDenied)
+		assert.Equal(t, 1, hook.calls)
+	})
+
+	t.Run("AdmissionOfUpdates", func(t *testing.T) {
+		setup(t)
+
+		assert.NoError(t, storage.Create(newProfile("test")))
+
+		stale, err := storage.Get(&v1alpha1.ProfileSpec{}, nsName)
+		assert.NoError(t, err)
+		assert.NoError(t, storage.Update(stale))
+
+		hook := &denyAllHook{}
+		apiServer.RegisterAdmissionHooks([]types.AdmissionHook{hook})
+
+		// -- missing and stale resources are rejected before the hooks run.
+		assert.ErrorIs(t, storage.Update(newProfile("missing")), types.ErrNotFound)
+		assert.ErrorIs(t, storage.Update(stale), types.ErrConflict)
+		assert.Zero(t, hook.calls)
+
+		fresh, err := storage.Get(&v1alpha1.ProfileSpec{}, nsName)
+		assert.NoError(t, err)
+		assert.ErrorIs(t, storage.Update(fresh), types.ErrDenied)
+		assert.Equal(t, 1, hook.calls)
+	})
+}
+
+// denyAllHook is an admission hook denying every operation.
+type denyAllHook struct {
+	calls int
+}
+
+func (h *denyAllHook) Name() string {
+	return "deny-all"
+}
+
+func (h *denyAllHook) Review(
+	_ types.Operation,
+	_ types.Resource[types.APIVersionKind],
+) (types.Resource[types.APIVersionKind], error) {
+	h.calls++
+
+	return types.Resource[types.APIVersionKind]{}, types.ErrDenied
+}
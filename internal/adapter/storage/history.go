@@ -0,0 +1,271 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storageadapter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+)
+
+//----------------------------------------------------------------------------------------------------------------------
+// History
+//
+// Revisions of a resource are stored in the history directory of its namespace using the following convention:
+// - Path: {{ namespace }}/.history/{{ T.APIVersion() }}.{{ T.Kind() }}.{{ Name }}/{{ revision }}.{{ encoding }}
+//
+// The encoding of a revision is the encoding of the resource at the time it was archived.
+//----------------------------------------------------------------------------------------------------------------------
+
+// History implements the types.HistoryStorage interface.
+func (fs *filesystem) History(
+	avk types.APIVersionKind,
+	nsName types.NamespacedName,
+) ([]types.Revision, error) {
+	if err := types.ValidateAPIVersion(avk.APIVersion()); err != nil {
+		return nil, flaterrors.Join(err, errAPIVersionMustBeSpecified)
+	}
+
+	if err := types.ValidateNamespacedName(nsName); err != nil {
+		return nil, err
+	}
+
+	historyDir := fs.computeHistoryAbsPath(avk, nsName)
+
+	revisions, err := fs.listRevisions(historyDir)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]types.Revision, 0, len(revisions))
+	for _, revision := range revisions {
+		info, err := os.Stat(revision.path)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := fs.read(revision.path)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, types.Revision{
+			Revision:        revision.revision,
+			ResourceVersion: res.Metadata.ResourceVersion,
+			Timestamp:       info.ModTime(),
+		})
+	}
+
+	return out, nil
+}
+
+// GetRevision implements the types.HistoryStorage interface.
+func (fs *filesystem) GetRevision(
+	avk types.APIVersionKind,
+	nsName types.NamespacedName,
+	revision int,
+) (types.Resource[types.APIVersionKind], error) {
+	if err := types.ValidateAPIVersion(avk.APIVersion()); err != nil {
+		return types.Resource[types.APIVersionKind]{}, flaterrors.Join(
+			err,
+			errAPIVersionMustBeSpecified,
+		)
+	}
+
+	if err := types.ValidateNamespacedName(nsName); err != nil {
+		return types.Resource[types.APIVersionKind]{}, err
+	}
+
+	revisions, err := fs.listRevisions(fs.computeHistoryAbsPath(avk, nsName))
+	if err != nil {
+		return types.Resource[types.APIVersionKind]{}, err
+	}
+
+	i := slices.IndexFunc(revisions, func(r revisionFile) bool { return r.revision == revision })
+	if i < 0 {
+		return types.Resource[types.APIVersionKind]{}, flaterrors.Join(
+			types.ErrNotFound,
+			fmt.Errorf("revision %d", revision),
+		)
+	}
+
+	v, err := fs.read(revisions[i].path)
+	if os.IsNotExist(err) {
+		return types.Resource[types.APIVersionKind]{}, flaterrors.Join(
+			err,
+			types.ErrNotFound,
+			fmt.Errorf("revision %d", revision),
+		)
+	} else if err != nil {
+		return types.Resource[types.APIVersionKind]{}, err
+	}
+
+	return v, nil
+}
+
+// archive copies the stored version of a resource to its history and prunes the revisions exceeding
+// the history limit. It is a no-op if the resource does not exist or if history is disabled.
+// The caller must hold the namespace lock.
+func (fs *filesystem) archive(avk types.APIVersionKind, nsName types.NamespacedName) error {
+	if fs.historyLimit <= 0 {
+		return nil
+	}
+
+	src := fs.findResourceAbsPath(avk, nsName)
+
+	info, err := os.Stat(src)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	b, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+
+	historyDir := fs.computeHistoryAbsPath(avk, nsName)
+	if err := os.MkdirAll(historyDir, 0777); err != nil {
+		return err
+	}
+
+	if err := removeTmpFiles(historyDir); err != nil {
+		return err
+	}
+
+	revisions, err := fs.listRevisions(historyDir)
+	if err != nil {
+		return err
+	}
+
+	next := 1
+	if len(revisions) > 0 {
+		next = revisions[len(revisions)-1].revision + 1
+	}
+
+	_, encoding, _ := splitEncoding(src)
+	dest := filepath.Join(historyDir, fmt.Sprintf("%d.%s", next, encoding))
+
+	tmp, err := os.CreateTemp(historyDir, fmt.Sprintf("%s%d.*", tmpFilePrefix, next))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) //nolint: errcheck // no-op once renamed
+
+	if err := writeAndSync(tmp, b); err != nil {
+		return err
+	}
+
+	// -- The timestamp of a revision is the time at which it was written.
+	if err := os.Chtimes(tmp.Name(), info.ModTime(), info.ModTime()); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return err
+	}
+
+	// -- prune the oldest revisions.
+	revisions = append(revisions, revisionFile{revision: next, path: dest})
+	for len(revisions) > fs.historyLimit {
+		if err := os.Remove(revisions[0].path); err != nil {
+			return err
+		}
+		revisions = revisions[1:]
+	}
+
+	return nil
+}
+
+// latestResourceVersion returns the resourceVersion of the latest revision of a resource, or an
+// empty string if the resource has no history.
+func (fs *filesystem) latestResourceVersion(
+	avk types.APIVersionKind,
+	nsName types.NamespacedName,
+) (string, error) {
+	revisions, err := fs.listRevisions(fs.computeHistoryAbsPath(avk, nsName))
+	if err != nil || len(revisions) == 0 {
+		return "", err
+	}
+
+	res, err := fs.read(revisions[len(revisions)-1].path)
+	if err != nil {
+		return "", err
+	}
+
+	return res.Metadata.ResourceVersion, nil
+}
+
+// revisionFile is a file storing a revision.
+type revisionFile struct {
+	revision int
+	path     string
+}
+
+// listRevisions returns the revisions found in historyDir, sorted by revision number.
+func (fs *filesystem) listRevisions(historyDir string) ([]revisionFile, error) {
+	dentries, err := os.ReadDir(historyDir)
+	if os.IsNotExist(err) {
+		return []revisionFile{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	out := make([]revisionFile, 0, len(dentries))
+	for _, dentry := range dentries {
+		filename := dentry.Name()
+		if dentry.IsDir() {
+			continue
+		}
+
+		name, _, ok := splitEncoding(filename)
+		if !ok {
+			continue
+		}
+
+		revision, err := strconv.Atoi(name)
+		if err != nil {
+			continue
+		}
+
+		out = append(out, revisionFile{revision: revision, path: filepath.Join(historyDir, filename)})
+	}
+
+	slices.SortFunc(out, func(a, b revisionFile) int {
+		return a.revision - b.revision
+	})
+
+	return out, nil
+}
+
+// computeHistoryAbsPath computes the path to the directory containing the revisions of a resource.
+func (fs *filesystem) computeHistoryAbsPath(
+	avk types.APIVersionKind,
+	nsName types.NamespacedName,
+) string {
+	return filepath.Join(
+		fs.computeNamespaceAbsPath(nsName.Namespace),
+		historyDirname,
+		fs.basenameWithoutExt(avk, nsName.Name),
+	)
+}
@@ -0,0 +1,32 @@
+//go:build !unix
+
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storageadapter
+
+import "os"
+
+// flock is a no-op on platforms that do not support flock(2).
+// Writes remain atomic but concurrent writers are not serialized.
+func flock(*os.File) error {
+	return nil
+}
+
+// funlock is a no-op on platforms that do not support flock(2).
+func funlock(*os.File) error {
+	return nil
+}
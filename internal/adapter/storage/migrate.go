@@ -0,0 +1,106 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storageadapter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexandremahdhaoui/vib/internal/types"
+)
+
+//----------------------------------------------------------------------------------------------------------------------
+// Encoding migration
+//----------------------------------------------------------------------------------------------------------------------
+
+// MigrateEncoding implements the types.EncodingMigrator interface.
+// Revisions are not migrated, as they can be read with any encoding.
+func (fs *filesystem) MigrateEncoding() ([]types.Migration, error) {
+	namespaces, err := fs.Namespaces()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]types.Migration, 0)
+	for _, namespace := range namespaces {
+		migrations, err := fs.migrateNamespace(namespace)
+		out = append(out, migrations...)
+		if err != nil {
+			return out, err
+		}
+	}
+
+	return out, nil
+}
+
+// migrateNamespace rewrites the resources of a namespace with the encoding of the storage.
+func (fs *filesystem) migrateNamespace(namespace string) ([]types.Migration, error) {
+	unlock, err := fs.lockNamespace(namespace)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	nsDir := fs.computeNamespaceAbsPath(namespace)
+
+	dentries, err := os.ReadDir(nsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]types.Migration, 0)
+	for _, dentry := range dentries {
+		filename := dentry.Name()
+		if dentry.IsDir() || strings.HasPrefix(filename, ".") {
+			continue
+		}
+
+		name, encoding, ok := splitEncoding(filename)
+		if !ok || encoding == fs.codec.Encoding() {
+			continue
+		}
+
+		path := filepath.Join(nsDir, filename)
+
+		// -- if the resource is also stored with the encoding of the storage, e.g. if a previous
+		// write was interrupted, that file is the latest version.
+		res, err := fs.read(fs.findAbsPath(filepath.Join(nsDir, name)))
+		if err != nil {
+			return out, err
+		}
+
+		// -- writeAtomic removes the files storing the resource with other encodings.
+		if err := fs.writeAtomic(res); err != nil {
+			return out, err
+		}
+
+		// -- the file may not follow the naming convention.
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return out, err
+		}
+
+		out = append(out, types.Migration{
+			Namespace: namespace,
+			Filename:  filename,
+			From:      encoding,
+			To:        fs.codec.Encoding(),
+		})
+	}
+
+	return out, nil
+}
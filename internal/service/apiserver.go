@@ -18,8 +18,10 @@ package service
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
+	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
 	"github.com/alexandremahdhaoui/vib/internal/types"
 )
 
@@ -30,12 +32,30 @@ type (
 		// avkFactory function that instantiate the zero-valued struct
 		// corresponding to the AVK.
 		avkFactory types.AVKFunc
+		// names are the names the kind can be referred to with. The names
+		// of spokes are ignored.
+		names types.ResourceNames
+		// defaulters, mutators and validators are the hooks run on the
+		// resources of the AVK.
+		defaulters []types.DefaulterFunc
+		mutators   []types.MutatorFunc
+		validators []types.ValidatorFunc
+		// hub is the APIVersion of the hub of the kind, or empty if the
+		// AVK is the hub. convertToHub and convertFromHub convert the
+		// specs of a spoke to and from the hub.
+		hub            types.APIVersion
+		convertToHub   types.ConversionFunc
+		convertFromHub types.ConversionFunc
 	}
 )
 
 // apiServer implements the types.APIServer interface.
 type apiServer struct {
-	leavesByHash          map[avkHash]leaf
+	admissionHooks []types.AdmissionHook
+	leavesByHash   map[avkHash]leaf
+	// registeredHashes are the hashes of the registered AVKs, in the order
+	// they were registered.
+	registeredHashes      []avkHash
 	registeredAPIVersions []types.APIVersion
 }
 
@@ -62,15 +82,300 @@ func (a *apiServer) Get(avk types.APIVersionKind) (types.Resource[types.APIVersi
 	}, nil
 }
 
+// List implements the types.APIServer interface.
+// APIVersionKinds are sorted by APIVersion and Kind. Spokes are not listed.
+func (a *apiServer) List() []types.APIVersionKind {
+	out := make([]types.APIVersionKind, 0, len(a.leavesByHash))
+	for _, hash := range a.sortedHashes() {
+		if l := a.leavesByHash[hash]; l.hub == "" {
+			out = append(out, l.avkFactory())
+		}
+	}
+
+	return out
+}
+
+// APIResources implements the types.APIServer interface.
+// Every kind is namespaced, as resources are always stored in a namespace.
+func (a *apiServer) APIResources() []types.APIResource {
+	out := make([]types.APIResource, 0, len(a.leavesByHash))
+	for _, hash := range a.sortedHashes() {
+		l := a.leavesByHash[hash]
+		if l.hub != "" {
+			continue
+		}
+
+		avk := l.avkFactory()
+		out = append(out, types.APIResource{
+			APIVersion: avk.APIVersion(),
+			Kind:       avk.Kind(),
+			Names:      l.names,
+			Namespaced: true,
+		})
+	}
+
+	return out
+}
+
+// Versions implements the types.APIServer interface.
+// Spokes are sorted by APIVersion.
+func (a *apiServer) Versions(kind types.Kind) []types.APIVersion {
+	var hub types.APIVersion
+	spokes := make([]types.APIVersion, 0)
+
+	for _, l := range a.leavesByHash {
+		avk := l.avkFactory()
+		if !strings.EqualFold(avk.Kind(), kind) {
+			continue
+		}
+
+		if l.hub == "" {
+			hub = avk.APIVersion()
+		} else {
+			spokes = append(spokes, avk.APIVersion())
+		}
+	}
+
+	if hub == "" {
+		return spokes
+	}
+
+	slices.Sort(spokes)
+
+	return append([]types.APIVersion{hub}, spokes...)
+}
+
+// Convert implements the types.APIServer interface.
+// Spokes are converted to the hub of their kind, and from the hub to the requested APIVersion.
+// The metadata and the YAML document of the resource are preserved.
+func (a *apiServer) Convert(
+	res types.Resource[types.APIVersionKind],
+	apiVersion types.APIVersion,
+) (types.Resource[types.APIVersionKind], error) {
+	l, err := a.getLeaf(types.NewAVKFromResource(res))
+	if err != nil {
+		return types.Resource[types.APIVersionKind]{}, err
+	}
+
+	if l.hub != "" {
+		if res, err = convertSpec(res, l.convertToHub); err != nil {
+			return types.Resource[types.APIVersionKind]{}, err
+		}
+	}
+
+	if apiVersion == "" || strings.EqualFold(apiVersion, res.APIVersion) {
+		return res, nil
+	}
+
+	target, err := a.getLeaf(types.NewAPIVersionKind(apiVersion, res.Kind))
+	if err != nil {
+		return types.Resource[types.APIVersionKind]{}, flaterrors.Join(
+			err,
+			fmt.Errorf("kind %q has no APIVersion %q", res.Kind, apiVersion),
+		)
+	}
+
+	if !strings.EqualFold(target.hub, res.APIVersion) {
+		return types.Resource[types.APIVersionKind]{}, flaterrors.Join(
+			types.ErrVal,
+			fmt.Errorf("cannot convert %q from %q to %q", res.Kind, res.APIVersion, apiVersion),
+		)
+	}
+
+	return convertSpec(res, target.convertFromHub)
+}
+
+// Schema implements the types.APIServer interface.
+// The schema is generated from the Go type instantiated by the factory of the AVK.
+func (a *apiServer) Schema(avk types.APIVersionKind) (*types.Schema, error) {
+	l, err := a.getLeaf(avk)
+	if err != nil {
+		return nil, err
+	}
+
+	return types.NewResourceSchema(l.avkFactory()), nil
+}
+
+// Default implements the types.APIServer interface.
+func (a *apiServer) Default(res *types.Resource[types.APIVersionKind]) error {
+	l, err := a.getLeaf(types.NewAVKFromResource(*res))
+	if err != nil {
+		return err
+	}
+
+	for _, f := range l.defaulters {
+		f(res)
+	}
+
+	return nil
+}
+
+// Validate implements the types.APIServer interface.
+// Registered validators only run if the resource passes the generic validation.
+func (a *apiServer) Validate(res types.Resource[types.APIVersionKind]) error {
+	l, err := a.getLeaf(types.NewAVKFromResource(res))
+	if err != nil {
+		return err
+	}
+
+	if err := types.ValidateResource(res); err != nil {
+		return err
+	}
+
+	var errs error
+	for _, f := range l.validators {
+		errs = flaterrors.Join(errs, f(res))
+	}
+
+	if errs != nil {
+		return flaterrors.Join(
+			errs,
+			fmt.Errorf("resource %q in namespace %q", res.Metadata.Name, res.Metadata.Namespace),
+		)
+	}
+
+	return nil
+}
+
+// Admit implements the types.APIServer interface.
+// Admission hooks cannot change the apiVersion, kind, name, namespace or resourceVersion of
+// resources.
+func (a *apiServer) Admit(op types.Operation, res *types.Resource[types.APIVersionKind]) error {
+	if op == types.DeleteOperation {
+		for _, hook := range a.admissionHooks {
+			if _, err := hook.Review(op, *res); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	converted, err := a.Convert(*res, "")
+	if err != nil {
+		return err
+	}
+
+	*res = converted
+
+	if err := a.Default(res); err != nil {
+		return err
+	}
+
+	l, err := a.getLeaf(types.NewAVKFromResource(*res))
+	if err != nil {
+		return err
+	}
+
+	for _, f := range l.mutators {
+		if err := f(res); err != nil {
+			return err
+		}
+	}
+
+	ref := types.NewReferenceFromResource(*res)
+	for _, hook := range a.admissionHooks {
+		out, err := hook.Review(op, *res)
+		if err != nil {
+			return err
+		}
+
+		// -- the resourceVersion is checked by the storage to detect conflicts.
+		if types.NewReferenceFromResource(out) != ref ||
+			out.Metadata.ResourceVersion != res.Metadata.ResourceVersion {
+			return flaterrors.Join(
+				types.ErrVal,
+				fmt.Errorf(
+					"admission hook %q cannot change the apiVersion, kind, name, namespace or resourceVersion of %s",
+					hook.Name(),
+					ref,
+				),
+			)
+		}
+
+		*res = out
+	}
+
+	return a.Validate(*res)
+}
+
+// RegisterAdmissionHooks implements the types.APIServer interface.
+func (a *apiServer) RegisterAdmissionHooks(hooks []types.AdmissionHook) {
+	a.admissionHooks = append(a.admissionHooks, hooks...)
+}
+
 // Register implements the types.APIServer interface.
-func (a *apiServer) Register(avkFactory []types.AVKFunc) {
-	for _, f := range avkFactory {
-		avk := f()
+// Registrations of AVKs that are already registered, or of kinds whose
+// names are used by another kind, are skipped.
+func (a *apiServer) Register(registrations []types.Registration) error {
+	var errs error
+	for _, r := range registrations {
+		avk := r.Factory()
+		hash := a.computeAVKHash(avk)
+		if _, ok := a.leavesByHash[hash]; ok {
+			errs = flaterrors.Join(
+				errs,
+				types.ErrExists,
+				fmt.Errorf("apiVersion %q kind %q is already registered", avk.APIVersion(), avk.Kind()),
+			)
+
+			continue
+		}
+
+		names := types.NewResourceNames(avk.Kind(), r.Names)
+		if r.Hub == "" {
+			if err := a.checkNames(avk, names); err != nil {
+				errs = flaterrors.Join(errs, err)
+				continue
+			}
+		}
+
+		a.registeredHashes = append(a.registeredHashes, hash)
 		a.registeredAPIVersions = append(a.registeredAPIVersions, avk.APIVersion())
-		a.leavesByHash[a.computeAVKHash(avk)] = leaf{
-			avkFactory: f,
+		a.leavesByHash[hash] = leaf{
+			avkFactory: r.Factory,
+			names:      names,
+			defaulters: r.Defaulters,
+			mutators:   r.Mutators,
+			validators: r.Validators,
+
+			hub:            r.Hub,
+			convertToHub:   r.ConvertToHub,
+			convertFromHub: r.ConvertFromHub,
 		}
 	}
+
+	return errs
+}
+
+// checkNames returns types.ErrExists if one of the names of avk is a name of
+// another registered kind. Spokes are ignored, as their names are ignored.
+func (a *apiServer) checkNames(avk types.APIVersionKind, names types.ResourceNames) error {
+	for _, hash := range a.registeredHashes {
+		l := a.leavesByHash[hash]
+		if l.hub != "" {
+			continue
+		}
+
+		for _, name := range append([]string{names.Singular, names.Plural}, names.ShortNames...) {
+			if l.names.Matches(name) {
+				registered := l.avkFactory()
+				return flaterrors.Join(
+					types.ErrExists,
+					fmt.Errorf(
+						"name %q of apiVersion %q kind %q is used by apiVersion %q kind %q",
+						name,
+						avk.APIVersion(),
+						avk.Kind(),
+						registered.APIVersion(),
+						registered.Kind(),
+					),
+				)
+			}
+		}
+	}
+
+	return nil
 }
 
 func (a *apiServer) computeAVKHash(avk types.APIVersionKind) avkHash {
@@ -82,12 +387,57 @@ func (a *apiServer) computeAVKHash(avk types.APIVersionKind) avkHash {
 	)
 }
 
+// sortedHashes returns the hashes of the registered AVKs, sorted by
+// APIVersion and Kind.
+func (a *apiServer) sortedHashes() []avkHash {
+	hashes := make([]avkHash, 0, len(a.leavesByHash))
+	for hash := range a.leavesByHash {
+		hashes = append(hashes, hash)
+	}
+
+	slices.Sort(hashes)
+
+	return hashes
+}
+
+// getLeaf returns the leaf of avk. If the APIVersion of avk is empty, the
+// leaf of the preferred version of the kind is returned.
+// The kind of avk may be one of the names of the kind, e.g. "es".
 func (a *apiServer) getLeaf(avk types.APIVersionKind) (leaf, error) {
+	if l, err := a.getLeafByKind(avk); err == nil {
+		return l, nil
+	}
+
+	kind, ok := a.resolveKind(avk)
+	if !ok {
+		return leaf{}, types.ErrNotFound
+	}
+
+	return a.getLeafByKind(types.NewAPIVersionKind(avk.APIVersion(), kind))
+}
+
+// resolveKind returns the kind whose names match the kind of avk. The names
+// of a kind are the names of its preferred version. Kinds are resolved in the
+// order they were registered, thus built-in kinds are resolved first.
+func (a *apiServer) resolveKind(avk types.APIVersionKind) (types.Kind, bool) {
+	for _, hash := range a.registeredHashes {
+		l := a.leavesByHash[hash]
+		if l.hub == "" && l.names.Matches(avk.Kind()) {
+			return l.avkFactory().Kind(), true
+		}
+	}
+
+	return "", false
+}
+
+// getLeafByKind returns the leaf of avk, whose kind must be the registered
+// kind.
+func (a *apiServer) getLeafByKind(avk types.APIVersionKind) (leaf, error) {
 	if v := avk.APIVersion(); v == "" {
 		for _, v := range a.registeredAPIVersions {
 			newAVK := types.NewAPIVersionKind(v, avk.Kind())
 			l, ok := a.leavesByHash[a.computeAVKHash(newAVK)]
-			if ok {
+			if ok && l.hub == "" {
 				return l, nil
 			}
 		}
@@ -102,3 +452,31 @@ func (a *apiServer) getLeaf(avk types.APIVersionKind) (leaf, error) {
 
 	return l, nil
 }
+
+// convertSpec converts the spec of res with f, and sets the APIVersion and
+// Kind of res accordingly.
+func convertSpec(
+	res types.Resource[types.APIVersionKind],
+	f types.ConversionFunc,
+) (types.Resource[types.APIVersionKind], error) {
+	if f == nil {
+		return types.Resource[types.APIVersionKind]{}, flaterrors.Join(
+			types.ErrType,
+			fmt.Errorf("no conversion registered for %q %q", res.APIVersion, res.Kind),
+		)
+	}
+
+	spec, err := f(res.Spec)
+	if err != nil {
+		return types.Resource[types.APIVersionKind]{}, flaterrors.Join(
+			err,
+			fmt.Errorf("cannot convert %q from %q", res.Kind, res.APIVersion),
+		)
+	}
+
+	res.APIVersion = spec.APIVersion()
+	res.Kind = spec.Kind()
+	res.Spec = spec
+
+	return res, nil
+}
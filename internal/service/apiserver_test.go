@@ -0,0 +1,195 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service_test
+
+import (
+	"testing"
+
+	"github.com/alexandremahdhaoui/vib/internal/service"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+	"github.com/alexandremahdhaoui/vib/pkg/apis/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIServer_Get(t *testing.T) {
+	apiServer := service.NewAPIServer()
+	v1alpha1.RegisterWithManager(apiServer)
+
+	for _, name := range []string{"ExpressionSet", "expressionset", "expressionsets", "es", "ES"} {
+		res, err := apiServer.Get(types.NewAPIVersionKind("", name))
+		assert.NoError(t, err, name)
+		assert.Equal(t, v1alpha1.ExpressionSetKind, res.Kind, name)
+		assert.Equal(t, v1alpha1.APIVersion, res.APIVersion, name)
+	}
+
+	// -- names are resolved within the requested APIVersion.
+	res, err := apiServer.Get(types.NewAPIVersionKind(v1alpha1.LegacyAPIVersion, "es"))
+	assert.NoError(t, err)
+	assert.Equal(t, v1alpha1.LegacyAPIVersion, res.APIVersion)
+
+	_, err = apiServer.Get(types.NewAPIVersionKind("", "unknown"))
+	assert.ErrorIs(t, err, types.ErrNotFound)
+}
+
+func TestAPIServer_Register(t *testing.T) {
+	newRegistration := func(name, group string, names v1alpha1.ResourceDefinitionNames) types.Registration {
+		return v1alpha1.NewCustomRegistration(types.Resource[*v1alpha1.ResourceDefinitionSpec]{
+			APIVersion: v1alpha1.APIVersion,
+			Kind:       v1alpha1.ResourceDefinitionKind,
+			Metadata:   types.Metadata{Name: name, Namespace: types.VibSystemNamespace},
+			Spec:       &v1alpha1.ResourceDefinitionSpec{Group: group, Version: "v1", Names: names},
+		})
+	}
+
+	for _, tc := range []struct {
+		Name         string
+		Registration types.Registration
+		WantErr      bool
+	}{
+		{
+			Name:         "Valid",
+			Registration: newRegistration("widget", "aaa.example.com", v1alpha1.ResourceDefinitionNames{Kind: "Widget"}),
+		},
+		{
+			Name: "ShortNameOfAnotherKind",
+			Registration: newRegistration("widget", "aaa.example.com", v1alpha1.ResourceDefinitionNames{
+				Kind:       "Widget",
+				ShortNames: []string{"es"},
+			}),
+			WantErr: true,
+		},
+		{
+			Name: "SingularNameOfAnotherKind",
+			Registration: newRegistration("es", "aaa.example.com", v1alpha1.ResourceDefinitionNames{
+				Kind: "ExpressionSet",
+			}),
+			WantErr: true,
+		},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			apiServer := service.NewAPIServer()
+			v1alpha1.RegisterWithManager(apiServer)
+
+			err := apiServer.Register([]types.Registration{tc.Registration})
+			if tc.WantErr {
+				assert.ErrorIs(t, err, types.ErrExists)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			// -- names of built-in kinds are never shadowed.
+			res, err := apiServer.Get(types.NewAPIVersionKind("", "es"))
+			assert.NoError(t, err)
+			assert.Equal(t, v1alpha1.APIVersion, res.APIVersion)
+			assert.Len(t, apiServer.Versions(v1alpha1.ExpressionSetKind), 2)
+		})
+	}
+
+	t.Run("Twice", func(t *testing.T) {
+		apiServer := service.NewAPIServer()
+		v1alpha1.RegisterWithManager(apiServer)
+
+		registration := newRegistration("widget", "aaa.example.com", v1alpha1.ResourceDefinitionNames{Kind: "Widget"})
+		assert.NoError(t, apiServer.Register([]types.Registration{registration}))
+		assert.ErrorIs(t, apiServer.Register([]types.Registration{registration}), types.ErrExists)
+	})
+}
+
+func TestAPIServer_Admit(t *testing.T) {
+	for _, tc := range []struct {
+		Name    string
+		Mutate  func(res *types.Resource[types.APIVersionKind])
+		WantErr bool
+	}{
+		{
+			Name: "Spec",
+			Mutate: func(res *types.Resource[types.APIVersionKind]) {
+				res.Spec.(*v1alpha1.ExpressionSetSpec).ArbitraryKeys = []string{"set -o emacs"}
+			},
+		},
+		{
+			Name: "Labels",
+			Mutate: func(res *types.Resource[types.APIVersionKind]) {
+				res.Metadata.Labels = map[string]string{"team": "a"}
+			},
+		},
+		{
+			Name:    "Name",
+			Mutate:  func(res *types.Resource[types.APIVersionKind]) { res.Metadata.Name = "other" },
+			WantErr: true,
+		},
+		{
+			Name:    "Namespace",
+			Mutate:  func(res *types.Resource[types.APIVersionKind]) { res.Metadata.Namespace = "other" },
+			WantErr: true,
+		},
+		{
+			Name:    "ResourceVersion",
+			Mutate:  func(res *types.Resource[types.APIVersionKind]) { res.Metadata.ResourceVersion = "4" },
+			WantErr: true,
+		},
+		{
+			Name:    "ClearResourceVersion",
+			Mutate:  func(res *types.Resource[types.APIVersionKind]) { res.Metadata.ResourceVersion = "" },
+			WantErr: true,
+		},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			apiServer := service.NewAPIServer()
+			v1alpha1.RegisterWithManager(apiServer)
+			apiServer.RegisterAdmissionHooks([]types.AdmissionHook{&mutatingHook{mutate: tc.Mutate}})
+
+			res := types.Resource[types.APIVersionKind]{
+				APIVersion: v1alpha1.APIVersion,
+				Kind:       v1alpha1.ExpressionSetKind,
+				Metadata: types.Metadata{
+					Name:            "test",
+					Namespace:       types.DefaultNamespace,
+					ResourceVersion: "3",
+				},
+				Spec: &v1alpha1.ExpressionSetSpec{ArbitraryKeys: []string{"set -o vi"}},
+			}
+
+			err := apiServer.Admit(types.UpdateOperation, &res)
+			if tc.WantErr {
+				assert.ErrorIs(t, err, types.ErrVal)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "3", res.Metadata.ResourceVersion)
+		})
+	}
+}
+
+// mutatingHook is an admission hook applying mutate to the reviewed resources.
+type mutatingHook struct {
+	mutate func(res *types.Resource[types.APIVersionKind])
+}
+
+func (h *mutatingHook) Name() string {
+	return "mutating"
+}
+
+func (h *mutatingHook) Review(
+	_ types.Operation,
+	res types.Resource[types.APIVersionKind],
+) (types.Resource[types.APIVersionKind], error) {
+	h.mutate(&res)
+
+	return res, nil
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+// Operation is an operation performed on a resource.
+type Operation string
+
+const (
+	// CreateOperation is the creation of a resource.
+	CreateOperation Operation = "Create"
+	// UpdateOperation is the update of a resource.
+	UpdateOperation Operation = "Update"
+	// DeleteOperation is the deletion of a resource.
+	DeleteOperation Operation = "Delete"
+)
+
+// Operations lists the operations reviewed by admission hooks.
+var Operations = []Operation{CreateOperation, UpdateOperation, DeleteOperation}
+
+const (
+	// AdmissionReviewAPIVersion is the apiVersion of an AdmissionReview.
+	AdmissionReviewAPIVersion = "admission.vib.amahdha.com/v1alpha1"
+	// AdmissionReviewKind is the kind of an AdmissionReview.
+	AdmissionReviewKind = "AdmissionReview"
+)
+
+// AdmissionHook is the interface implemented by hooks reviewing the operations performed on resources
+// before they are persisted.
+type AdmissionHook interface {
+	// Name returns the name of the hook, used to report errors.
+	Name() string
+
+	// Review reviews an operation performed on res. It returns the resource, patched by the hook if
+	// applicable, or an error wrapping ErrDenied if the operation is denied.
+	Review(op Operation, res Resource[APIVersionKind]) (Resource[APIVersionKind], error)
+}
+
+type (
+	// AdmissionReview is the document exchanged with admission hooks. Hooks receive a review holding a
+	// request, and answer with a review holding a response.
+	AdmissionReview struct {
+		APIVersion APIVersion         `json:"apiVersion"`
+		Kind       Kind               `json:"kind"`
+		Request    *AdmissionRequest  `json:"request,omitempty"`
+		Response   *AdmissionResponse `json:"response,omitempty"`
+	}
+
+	// AdmissionRequest describes an operation performed on a resource.
+	AdmissionRequest struct {
+		// Operation is the operation performed on the resource.
+		Operation Operation `json:"operation"`
+		// Object is the resource being created or updated, or the resource being deleted.
+		Object Resource[APIVersionKind] `json:"object"`
+	}
+
+	// AdmissionResponse is the decision of an admission hook.
+	AdmissionResponse struct {
+		// Allowed is true if the operation is allowed.
+		Allowed bool `json:"allowed"`
+		// Messages explain why the operation is denied, or are printed as warnings if it is allowed.
+		Messages []string `json:"messages,omitempty"`
+		// Patch is a JSON Patch (RFC 6902) applied to the resource, if the operation is allowed.
+		// Patches are ignored when resources are deleted.
+		Patch []PatchOperation `json:"patch,omitempty"`
+	}
+
+	// PatchOperation is an operation of a JSON Patch (RFC 6902).
+	PatchOperation struct {
+		// Op is one of "add", "remove", "replace", "move", "copy" or "test".
+		Op string `json:"op"`
+		// Path is a JSON Pointer (RFC 6901) to the target location.
+		Path string `json:"path"`
+		// From is a JSON Pointer to the source location of "move" and "copy" operations.
+		From string `json:"from,omitempty"`
+		// Value is the value of "add", "replace" and "test" operations.
+		Value any `json:"value,omitempty"`
+	}
+)
+
+// NewAdmissionReview returns an AdmissionReview requesting the review of op performed on res.
+func NewAdmissionReview(op Operation, res Resource[APIVersionKind]) AdmissionReview {
+	return AdmissionReview{
+		APIVersion: AdmissionReviewAPIVersion,
+		Kind:       AdmissionReviewKind,
+		Request: &AdmissionRequest{
+			Operation: op,
+			Object:    res,
+		},
+	}
+}
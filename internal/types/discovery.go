@@ -0,0 +1,85 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import "strings"
+
+type (
+	// ResourceNames are the names a kind can be referred to with, e.g. "vib get es".
+	ResourceNames struct {
+		// Singular is the lowercase singular name of the kind, e.g. "expressionset".
+		Singular string `json:"singular,omitempty"`
+		// Plural is the lowercase plural name of the kind, e.g. "expressionsets".
+		Plural string `json:"plural,omitempty"`
+		// ShortNames are the short lowercase aliases of the kind, e.g. "es".
+		ShortNames []string `json:"shortNames,omitempty"`
+	}
+
+	// APIResource is the discovery data of a registered kind.
+	APIResource struct {
+		APIVersion APIVersion    `json:"apiVersion"`
+		Kind       Kind          `json:"kind"`
+		Names      ResourceNames `json:"names"`
+		// Namespaced is true if the resources of the kind are stored in namespaces.
+		Namespaced bool `json:"namespaced"`
+	}
+)
+
+// NewResourceNames returns the names of kind, defaulting the singular and plural names of names.
+// The plural name follows the English rules for regular nouns, e.g. "Policy" becomes "policies".
+func NewResourceNames(kind Kind, names ResourceNames) ResourceNames {
+	if names.Singular == "" {
+		names.Singular = strings.ToLower(kind)
+	}
+
+	if names.Plural == "" {
+		names.Plural = pluralize(names.Singular)
+	}
+
+	return names
+}
+
+// Matches returns true if name is the singular, plural or one of the short names, ignoring case.
+func (n ResourceNames) Matches(name string) bool {
+	if strings.EqualFold(name, n.Singular) || strings.EqualFold(name, n.Plural) {
+		return true
+	}
+
+	for _, shortName := range n.ShortNames {
+		if strings.EqualFold(name, shortName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pluralize returns the plural of a lowercase regular noun.
+func pluralize(s string) string {
+	switch {
+	case strings.HasSuffix(s, "s"),
+		strings.HasSuffix(s, "x"),
+		strings.HasSuffix(s, "z"),
+		strings.HasSuffix(s, "ch"),
+		strings.HasSuffix(s, "sh"):
+		return s + "es"
+	case strings.HasSuffix(s, "y") && len(s) > 1 && !strings.ContainsRune("aeiou", rune(s[len(s)-2])):
+		return s[:len(s)-1] + "ies"
+	default:
+		return s + "s"
+	}
+}
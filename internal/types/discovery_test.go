@@ -0,0 +1,38 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types_test
+
+import (
+	"testing"
+
+	"github.com/alexandremahdhaoui/vib/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewResourceNames(t *testing.T) {
+	for kind, plural := range map[types.Kind]string{
+		"ExpressionSet": "expressionsets",
+		"Policy":        "policies",
+		"Gateway":       "gateways",
+		"Alias":         "aliases",
+		"SSHHost":       "sshhosts",
+	} {
+		names := types.NewResourceNames(kind, types.ResourceNames{})
+		assert.Equal(t, plural, names.Plural, kind)
+		assert.True(t, names.Matches(kind), kind)
+	}
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+//go:generate go run ../../hack/gendocs
+
+import (
+	"reflect"
+	"strings"
+)
+
+// docs holds the documentation of the API types, keyed by "<pkgPath>.<TypeName>" for types and by
+// "<pkgPath>.<TypeName>.<FieldName>" for struct fields.
+var docs = make(map[string]string)
+
+// RegisterDocs registers the documentation of the types of the package pkgPath. Keys of typeDocs
+// are type names, e.g. "ProfileSpec", or struct field names, e.g. "ProfileSpec.Refs".
+// It is called by the files generated with hack/gendocs.
+func RegisterDocs(pkgPath string, typeDocs map[string]string) {
+	for name, doc := range typeDocs {
+		docs[pkgPath+"."+name] = doc
+	}
+}
+
+// TypeDoc returns the documentation of t, or an empty string.
+func TypeDoc(t reflect.Type) string {
+	return lookupDoc(t, "")
+}
+
+// FieldDoc returns the documentation of the field named fieldName of struct type t, or an empty
+// string.
+func FieldDoc(t reflect.Type, fieldName string) string {
+	return lookupDoc(t, "."+fieldName)
+}
+
+func lookupDoc(t reflect.Type, suffix string) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	// -- instances of generic types are documented by their generic type.
+	name, _, _ := strings.Cut(t.Name(), "[")
+	if name == "" {
+		return ""
+	}
+
+	return docs[t.PkgPath()+"."+name+suffix]
+}
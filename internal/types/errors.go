@@ -19,6 +19,7 @@ package types
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 var (
@@ -30,6 +31,8 @@ var (
 	ErrEncoding = errors.New("ERRENC: unsupported encoding")
 	// ErrExists is returned when a resource already exists.
 	ErrExists = errors.New("ERREXISTS: resource already exist")
+	// ErrConflict is returned when a resource was modified since it was last read.
+	ErrConflict = errors.New("ERRCONFLICT: resource has been modified")
 	// ErrNotFound is returned when a resource cannot be found.
 	ErrNotFound = errors.New("ERRNOTFOUND: resource cannot be found")
 	// ErrArgs is returned when unexpected arguments are provided.
@@ -38,9 +41,53 @@ var (
 	ErrVal = errors.New("ERRVAL: input cannot be validated")
 	// ErrRef is returned when an unexpected reference is encountered.
 	ErrRef = errors.New("ERRREF: unexpected reference")
+	// ErrDenied is returned when an operation is denied by an admission hook.
+	ErrDenied = errors.New("ERRDENIED: operation denied by an admission hook")
 )
 
 // ErrAtIndex returns an error with the given index.
 func ErrAtIndex(i int) error {
 	return fmt.Errorf("error is propably located at index %d", i)
 }
+
+// DecodeError is returned when a document cannot be decoded. It locates the error in the input.
+type DecodeError struct {
+	// File is the name of the decoded file, if known.
+	File string
+	// Document is the index of the document in the input.
+	Document int
+	// Line and Column locate the error in the input. They are 0 if unknown.
+	Line   int
+	Column int
+	// Path is the path of the erroneous field, e.g. "spec.resolverRef.namespace".
+	Path string
+	// Err is the underlying error.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *DecodeError) Error() string {
+	parts := make([]string, 0, 5)
+	if e.File != "" {
+		parts = append(parts, e.File)
+	}
+
+	parts = append(parts, fmt.Sprintf("document %d", e.Document))
+
+	if e.Line > 0 {
+		parts = append(parts, fmt.Sprintf("line %d, column %d", e.Line, e.Column))
+	}
+
+	if e.Path != "" {
+		parts = append(parts, e.Path)
+	}
+
+	parts = append(parts, e.Err.Error())
+
+	return strings.Join(parts, ": ")
+}
+
+// Unwrap returns the underlying error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"errors"
+
+	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
+)
+
+// ReferenceIndex is a reverse-reference index: it maps resources to the resources referencing them.
+type ReferenceIndex map[Reference][]Reference
+
+// NewReferenceIndex builds the reverse-reference index of the resources of every namespace of
+// storage. The storage must implement NamespaceLister.
+func NewReferenceIndex(apiServer APIServer, storage Storage) (ReferenceIndex, error) {
+	namespaceLister, ok := storage.(NamespaceLister)
+	if !ok {
+		return nil, flaterrors.Join(ErrType, errors.New("storage cannot list its namespaces"))
+	}
+
+	namespaces, err := namespaceLister.Namespaces()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(ReferenceIndex)
+	for _, namespace := range namespaces {
+		for _, avk := range apiServer.List() {
+			list, err := storage.List(avk, namespace)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, res := range list {
+				lister, ok := res.Spec.(ReferenceLister)
+				if !ok {
+					continue
+				}
+
+				for _, ref := range lister.References() {
+					out[ref] = append(out[ref], NewReferenceFromResource(res))
+				}
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// ReferencedBy returns the resources referencing ref.
+func (idx ReferenceIndex) ReferencedBy(ref Reference) []Reference {
+	return idx[ref]
+}
+
+// WithDependents returns refs and the resources transitively referencing them. Dependents are
+// ordered before the resources they reference, i.e. in an order they can be safely deleted.
+func (idx ReferenceIndex) WithDependents(refs []Reference) []Reference {
+	out := make([]Reference, 0, len(refs))
+	visited := make(map[Reference]struct{})
+
+	var visit func(ref Reference)
+	visit = func(ref Reference) {
+		if _, ok := visited[ref]; ok {
+			return
+		}
+
+		visited[ref] = struct{}{}
+
+		for _, dependent := range idx[ref] {
+			visit(dependent)
+		}
+
+		out = append(out, ref)
+	}
+
+	for _, ref := range refs {
+		visit(ref)
+	}
+
+	return out
+}
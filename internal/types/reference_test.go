@@ -0,0 +1,81 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types_test
+
+import (
+	"testing"
+
+	codecadapter "github.com/alexandremahdhaoui/vib/internal/adapter/codec"
+	storageadapter "github.com/alexandremahdhaoui/vib/internal/adapter/storage"
+	"github.com/alexandremahdhaoui/vib/internal/service"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+	"github.com/alexandremahdhaoui/vib/pkg/apis/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReferenceIndex(t *testing.T) {
+	apiServer := service.NewAPIServer()
+	v1alpha1.RegisterWithManager(apiServer)
+
+	storage, err := storageadapter.NewFilesystem(
+		apiServer,
+		codecadapter.NewYAML(),
+		codecadapter.NewDynamicResourceDecoder(apiServer),
+		t.TempDir(),
+		0,
+	)
+	assert.NoError(t, err)
+
+	resolver := v1alpha1.NewPlainResolver()
+	resolver.Metadata.Namespace = types.VibSystemNamespace
+
+	es := types.Resource[types.APIVersionKind]{
+		APIVersion: v1alpha1.APIVersion,
+		Kind:       v1alpha1.ExpressionSetKind,
+		Metadata:   types.Metadata{Name: "es", Namespace: types.DefaultNamespace},
+		Spec: &v1alpha1.ExpressionSetSpec{
+			ResolverRef: types.NewNamespacedNameFromMetadata(resolver.Metadata),
+		},
+	}
+
+	profile := types.Resource[types.APIVersionKind]{
+		APIVersion: v1alpha1.APIVersion,
+		Kind:       v1alpha1.ProfileKind,
+		Metadata:   types.Metadata{Name: "profile", Namespace: types.DefaultNamespace},
+		Spec:       &v1alpha1.ProfileSpec{Refs: []types.NamespacedName{{Name: "es"}}},
+	}
+
+	for _, res := range []types.Resource[types.APIVersionKind]{resolver, es, profile} {
+		assert.NoError(t, storage.Create(res))
+	}
+
+	index, err := types.NewReferenceIndex(apiServer, storage)
+	assert.NoError(t, err)
+
+	resolverRef := types.NewReferenceFromResource(resolver)
+	esRef := types.NewReferenceFromResource(es)
+	profileRef := types.NewReferenceFromResource(profile)
+
+	assert.Equal(t, []types.Reference{esRef}, index.ReferencedBy(resolverRef))
+	assert.Empty(t, index.ReferencedBy(profileRef))
+
+	// -- dependents are ordered before the resources they reference.
+	assert.Equal(t,
+		[]types.Reference{profileRef, esRef, resolverRef},
+		index.WithDependents([]types.Reference{resolverRef, esRef}),
+	)
+}
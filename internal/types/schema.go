@@ -0,0 +1,420 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
+)
+
+// JSONSchemaDraft is the JSON Schema dialect of the generated schemas.
+const JSONSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+type (
+	// Schema is a JSON Schema.
+	Schema struct {
+		Schema      string `json:"$schema,omitempty"`
+		Title       string `json:"title,omitempty"`
+		Description string `json:"description,omitempty"`
+
+		Type    string `json:"type,omitempty"`
+		Const   any    `json:"const,omitempty"`
+		Enum    []any  `json:"enum,omitempty"`
+		Default any    `json:"default,omitempty"`
+
+		Format    string   `json:"format,omitempty"`
+		Pattern   string   `json:"pattern,omitempty"`
+		MinLength *int     `json:"minLength,omitempty"`
+		MaxLength *int     `json:"maxLength,omitempty"`
+		Minimum   *float64 `json:"minimum,omitempty"`
+		Maximum   *float64 `json:"maximum,omitempty"`
+
+		Properties           map[string]*Schema `json:"properties,omitempty"`
+		Required             []string           `json:"required,omitempty"`
+		AdditionalProperties any                `json:"additionalProperties,omitempty"`
+		Items                *Schema            `json:"items,omitempty"`
+
+		OneOf []*Schema `json:"oneOf,omitempty"`
+	}
+
+	// SchemaExtender can be implemented by API types to express constraints of their schema that
+	// cannot be inferred from their Go type, e.g. a one-of between fields.
+	SchemaExtender interface {
+		ExtendSchema(schema *Schema)
+	}
+
+	// SchemaProvider can be implemented by API types whose schema is not defined by their Go type,
+	// e.g. the specs of kinds defined at runtime.
+	SchemaProvider interface {
+		SpecSchema() *Schema
+	}
+)
+
+var (
+	schemaExtenderType = reflect.TypeFor[SchemaExtender]()
+	schemaType         = reflect.TypeFor[Schema]()
+)
+
+// NewResourceSchema returns the JSON Schema of the resources of avk. The schema of the spec is
+// generated from the Go type of avk, using its json tags.
+func NewResourceSchema(avk APIVersionKind) *Schema {
+	out := NewSchema(reflect.TypeFor[Resource[struct{}]]())
+	out.Schema = JSONSchemaDraft
+	out.Title = avk.Kind()
+	out.Properties["apiVersion"].Const = avk.APIVersion()
+	out.Properties["kind"].Const = avk.Kind()
+	if provider, ok := avk.(SchemaProvider); ok {
+		out.Properties["spec"] = provider.SpecSchema()
+	} else {
+		out.Properties["spec"] = NewSchema(reflect.TypeOf(avk))
+	}
+
+	out.Description = out.Properties["spec"].Description
+	out.Required = []string{"apiVersion", "kind", "metadata"}
+
+	return out
+}
+
+// NewSchema returns the JSON Schema of t, using its json tags. Structs do not accept additional
+// properties, and their fields of kind bool, number or string are required unless their json tag
+// specifies omitempty. Descriptions are taken from the documentation registered with RegisterDocs.
+func NewSchema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	out := &Schema{Description: TypeDoc(t)}
+
+	// -- schemas are recursive: embedded schemas are described as objects.
+	if t == schemaType {
+		out.Type = "object"
+		return out
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		out.Type = "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		out.Type = "integer"
+	case reflect.Float32, reflect.Float64:
+		out.Type = "number"
+	case reflect.String:
+		out.Type = "string"
+	case reflect.Slice, reflect.Array:
+		out.Type = "array"
+		out.Items = NewSchema(t.Elem())
+	case reflect.Map:
+		out.Type = "object"
+		out.AdditionalProperties = NewSchema(t.Elem())
+	case reflect.Struct:
+		out.Type = "object"
+		out.Properties = make(map[string]*Schema)
+		out.AdditionalProperties = false
+
+		for _, field := range SchemaFields(t) {
+			out.Properties[field.Name] = NewSchema(field.Type)
+			if field.Description != "" {
+				out.Properties[field.Name].Description = field.Description
+			}
+
+			if field.Required {
+				out.Required = append(out.Required, field.Name)
+			}
+		}
+	}
+
+	if reflect.PointerTo(t).Implements(schemaExtenderType) {
+		reflect.New(t).Interface().(SchemaExtender).ExtendSchema(out)
+	}
+
+	return out
+}
+
+// SchemaField is a field of a struct, as seen in its JSON representation.
+type SchemaField struct {
+	Name        string
+	Description string
+	Type        reflect.Type
+	Required    bool
+}
+
+// SchemaFields returns the fields of struct type t, as seen in its JSON representation.
+// Unexported fields and fields whose json tag is "-" are ignored.
+func SchemaFields(t reflect.Type) []SchemaField {
+	out := make([]SchemaField, 0, t.NumField())
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+
+		out = append(out, SchemaField{
+			Name:        name,
+			Description: FieldDoc(t, field.Name),
+			Type:        field.Type,
+			Required:    !strings.Contains(opts, "omitempty") && isScalarKind(field.Type.Kind()),
+		})
+	}
+
+	return out
+}
+
+// isScalarKind returns true if values of kind k are encoded as a JSON boolean, number or string.
+func isScalarKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+
+	return false
+}
+
+// Validate validates v against the schema. The value is compared with the schema through its JSON
+// representation. Only the keywords of Schema are supported, and "format" is ignored.
+func (s *Schema) Validate(v any) error {
+	v, err := toJSONValue(v)
+	if err != nil {
+		return err
+	}
+
+	if err := s.validate(v, ""); err != nil {
+		return flaterrors.Join(ErrVal, err)
+	}
+
+	return nil
+}
+
+// ApplyDefaults sets the default values of the properties missing from v, an object decoded from
+// JSON, and of their nested properties.
+func (s *Schema) ApplyDefaults(v map[string]any) {
+	if s == nil {
+		return
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(s.Properties)) {
+		property := s.Properties[name]
+		if _, ok := v[name]; !ok && property.Default != nil {
+			if value, err := toJSONValue(property.Default); err == nil {
+				v[name] = value
+			}
+		}
+
+		if child, ok := v[name].(map[string]any); ok {
+			property.ApplyDefaults(child)
+		}
+	}
+}
+
+func (s *Schema) validate(v any, path string) error {
+	if s == nil {
+		return nil
+	}
+
+	fail := func(format string, args ...any) error {
+		at := path
+		if at == "" {
+			at = "."
+		}
+
+		return fmt.Errorf("%s: %s", at, fmt.Sprintf(format, args...))
+	}
+
+	if s.Type != "" && !jsonTypeMatches(s.Type, v) {
+		return fail("expected %s, got %s", s.Type, jsonTypeName(v))
+	}
+
+	var errs error
+	if s.Const != nil && !jsonEqual(s.Const, v) {
+		errs = flaterrors.Join(errs, fail("must be %v", s.Const))
+	}
+
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool { return jsonEqual(e, v) }) {
+		errs = flaterrors.Join(errs, fail("must be one of %v", s.Enum))
+	}
+
+	switch value := v.(type) {
+	case string:
+		length := utf8.RuneCountInString(value)
+		if s.MinLength != nil && length < *s.MinLength {
+			errs = flaterrors.Join(errs, fail("must be at least %d characters long", *s.MinLength))
+		}
+
+		if s.MaxLength != nil && length > *s.MaxLength {
+			errs = flaterrors.Join(errs, fail("must be at most %d characters long", *s.MaxLength))
+		}
+
+		if s.Pattern != "" {
+			re, err := regexp.Compile(s.Pattern)
+			if err != nil {
+				errs = flaterrors.Join(errs, fail("invalid pattern %q", s.Pattern))
+			} else if !re.MatchString(value) {
+				errs = flaterrors.Join(errs, fail("must match %q", s.Pattern))
+			}
+		}
+	case float64:
+		if s.Minimum != nil && value < *s.Minimum {
+			errs = flaterrors.Join(errs, fail("must be greater than or equal to %v", *s.Minimum))
+		}
+
+		if s.Maximum != nil && value > *s.Maximum {
+			errs = flaterrors.Join(errs, fail("must be less than or equal to %v", *s.Maximum))
+		}
+	case []any:
+		for i, elem := range value {
+			errs = flaterrors.Join(errs, s.Items.validate(elem, fmt.Sprintf("%s[%d]", path, i)))
+		}
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := value[name]; !ok {
+				errs = flaterrors.Join(errs, fail("missing required property %q", name))
+			}
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(value)) {
+			propertyPath := path + "." + name
+			if property, ok := s.Properties[name]; ok {
+				errs = flaterrors.Join(errs, property.validate(value[name], propertyPath))
+				continue
+			}
+
+			switch additional := s.AdditionalProperties.(type) {
+			case bool:
+				if !additional {
+					errs = flaterrors.Join(errs, fail("unknown property %q", name))
+				}
+			case nil:
+			default:
+				schema, err := toSchema(additional)
+				if err != nil {
+					return err
+				}
+
+				errs = flaterrors.Join(errs, schema.validate(value[name], propertyPath))
+			}
+		}
+	}
+
+	if len(s.OneOf) > 0 {
+		matches := 0
+		for _, branch := range s.OneOf {
+			if branch.validate(v, path) == nil {
+				matches++
+			}
+		}
+
+		if matches != 1 {
+			errs = flaterrors.Join(errs, fail("must match exactly one schema of oneOf, matched %d", matches))
+		}
+	}
+
+	return errs
+}
+
+// toSchema converts the value of additionalProperties to a Schema.
+func toSchema(v any) (*Schema, error) {
+	if schema, ok := v.(*Schema); ok {
+		return schema, nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	out := new(Schema)
+	if err := json.Unmarshal(b, out); err != nil {
+		return nil, flaterrors.Join(err, ErrVal, errors.New("invalid additionalProperties"))
+	}
+
+	return out, nil
+}
+
+// toJSONValue returns the JSON representation of v, as decoded by encoding/json into an any.
+func toJSONValue(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var out any
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// jsonEqual returns true if a and b have the same JSON representation.
+func jsonEqual(a, b any) bool {
+	a, errA := toJSONValue(a)
+	b, errB := toJSONValue(b)
+
+	return errA == nil && errB == nil && reflect.DeepEqual(a, b)
+}
+
+// jsonTypeMatches returns true if v, a value decoded from JSON, is of the given JSON Schema type.
+func jsonTypeMatches(schemaType string, v any) bool {
+	if schemaType == "integer" {
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	}
+
+	return schemaType == jsonTypeName(v)
+}
+
+// jsonTypeName returns the JSON Schema type of v, a value decoded from JSON.
+func jsonTypeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
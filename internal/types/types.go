@@ -17,21 +17,60 @@ limitations under the License.
 package types
 
 import (
+	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
+	yaml "sigs.k8s.io/yaml/goyaml.v3"
 )
 
 type (
 	// APIServer is the interface that defines the methods for an API server.
 	APIServer interface {
-		// Register registers a new APIVersion to the APIServer.
-		Register(avkFactory []AVKFunc)
+		// Register registers new APIVersionKinds to the APIServer, with their hooks. It returns
+		// types.ErrExists if an APIVersionKind is already registered; the other APIVersionKinds are
+		// registered.
+		Register(registrations []Registration) error
 
 		// Get will return a zero valued instance of a Resource corresponding
-		// to the return AVK.
+		// to the return AVK. The kind of the AVK may be one of its names, e.g. its
+		// plural or short names.
 		Get(avk APIVersionKind) (Resource[APIVersionKind], error)
+
+		// List returns a zero valued instance of the preferred version of every registered kind.
+		List() []APIVersionKind
+
+		// APIResources returns the discovery data of the preferred version of every registered
+		// kind, sorted by APIVersion and Kind.
+		APIResources() []APIResource
+
+		// Versions returns the registered APIVersions of a kind, starting with its preferred
+		// version.
+		Versions(kind Kind) []APIVersion
+
+		// Convert converts a resource to the given APIVersion of its kind, through the hub of the
+		// kind. The resource is converted to the preferred version if apiVersion is empty.
+		Convert(res Resource[APIVersionKind], apiVersion APIVersion) (Resource[APIVersionKind], error)
+
+		// Schema returns the JSON Schema of the resources of the given AVK.
+		Schema(avk APIVersionKind) (*Schema, error)
+
+		// Default runs the defaulters registered for the kind of the resource.
+		Default(res *Resource[APIVersionKind]) error
+
+		// Validate validates the resource and runs the validators registered for its kind.
+		Validate(res Resource[APIVersionKind]) error
+
+		// Admit converts to the preferred version, defaults, mutates, reviews with the admission
+		// hooks and validates a resource, in that order. It must be called before a resource is
+		// written. Resources being deleted are only reviewed by the admission hooks.
+		Admit(op Operation, res *Resource[APIVersionKind]) error
+
+		// RegisterAdmissionHooks registers admission hooks reviewing the resources of every kind.
+		// Hooks run in the order they are registered.
+		RegisterAdmissionHooks(hooks []AdmissionHook)
 	}
 
 	// APIVersionKind is the interface that defines the methods for an API version and kind.
@@ -58,6 +97,42 @@ type (
 		Read() ([]Resource[T], error)
 	}
 
+	// EncodingMigrator is the interface implemented by storages able to rewrite the resources stored
+	// with another encoding than their own.
+	EncodingMigrator interface {
+		// MigrateEncoding rewrites every resource that is not stored with the encoding of the storage.
+		// Resources are not modified: their resourceVersion is unchanged.
+		MigrateEncoding() ([]Migration, error)
+	}
+
+	// HistoryStorage is the interface implemented by storages that keep the previous versions of
+	// resources.
+	HistoryStorage interface {
+		// History lists the revisions of a resource, ordered from the oldest to the newest.
+		// The history of a deleted resource is kept until its revisions are pruned.
+		History(avk APIVersionKind, namespacedName NamespacedName) ([]Revision, error)
+
+		// GetRevision gets a resource as it was at the given revision. It returns
+		// types.ErrNotFound if the revision cannot be found.
+		GetRevision(
+			avk APIVersionKind,
+			namespacedName NamespacedName,
+			revision int,
+		) (Resource[APIVersionKind], error)
+	}
+
+	// NamespaceLister is the interface implemented by storages able to list their namespaces.
+	NamespaceLister interface {
+		// Namespaces returns the namespaces of the storage, sorted by name.
+		Namespaces() ([]string, error)
+	}
+
+	// ReferenceLister is the interface implemented by specs referencing other resources.
+	ReferenceLister interface {
+		// References returns the resources referenced by the spec. Unset references are omitted.
+		References() []Reference
+	}
+
 	// Renderer is the interface that defines the methods for a renderer.
 	Renderer interface {
 		Render(storage Storage) (string, error)
@@ -79,9 +154,12 @@ type (
 		Create(Resource[APIVersionKind]) error
 
 		// Update updates a resource. It returns types.ErrNotFound if named resource cannot be found.
+		// If the provided resource specifies a Metadata.ResourceVersion that does not match the
+		// stored one, Update returns types.ErrConflict.
 		Update(v Resource[APIVersionKind]) error
 
-		// Delete deletes a resource in the store. Delete is idempotent.
+		// Delete deletes a resource in the store. It returns types.ErrNotFound if named resource
+		// cannot be found.
 		Delete(avk APIVersionKind, namespacedName NamespacedName) error
 	}
 
@@ -100,10 +178,33 @@ const (
 
 // NamespacedName is a namespaced name.
 type NamespacedName struct {
-	Name      string `json:"name"`
+	// Name is the name of the referenced resource.
+	Name string `json:"name"`
+	// Namespace is the namespace of the referenced resource. Defaults to "default".
 	Namespace string `json:"namespace,omitempty"`
 }
 
+// Reference is a reference to a resource.
+type Reference struct {
+	APIVersion     APIVersion
+	Kind           Kind
+	NamespacedName NamespacedName
+}
+
+// String returns a human-readable representation of the reference.
+func (r Reference) String() string {
+	return fmt.Sprintf("%s %q in namespace %q", r.Kind, r.NamespacedName.Name, r.NamespacedName.Namespace)
+}
+
+// NewReferenceFromResource returns a reference to the given resource.
+func NewReferenceFromResource[T any](res Resource[T]) Reference {
+	return Reference{
+		APIVersion:     res.APIVersion,
+		Kind:           res.Kind,
+		NamespacedName: NewNamespacedNameFromMetadata(res.Metadata),
+	}
+}
+
 // NewNamespacedNameFromMetadata returns a new NamespacedName from the given metadata.
 func NewNamespacedNameFromMetadata(metadata Metadata) NamespacedName {
 	namespace := metadata.Namespace
@@ -117,9 +218,71 @@ func NewNamespacedNameFromMetadata(metadata Metadata) NamespacedName {
 	}
 }
 
+// Migration describes a resource whose encoding was migrated.
+type Migration struct {
+	// Namespace is the namespace of the resource.
+	Namespace string `json:"namespace"`
+	// Filename is the name of the migrated file.
+	Filename string `json:"filename"`
+	// From is the previous encoding of the resource.
+	From Encoding `json:"from"`
+	// To is the new encoding of the resource.
+	To Encoding `json:"to"`
+}
+
+// Revision describes a previous version of a resource.
+type Revision struct {
+	// Revision is the number identifying the revision. Revision numbers are increasing.
+	Revision int `json:"revision"`
+	// ResourceVersion is the resourceVersion the resource had at this revision.
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+	// Timestamp is the time at which this version of the resource was written.
+	Timestamp time.Time `json:"timestamp"`
+}
+
 // AVKFunc is a function that returns an APIVersionKind.
 type AVKFunc func() APIVersionKind
 
+type (
+	// DefaulterFunc sets the default values of a resource.
+	DefaulterFunc func(res *Resource[APIVersionKind])
+
+	// MutatorFunc modifies a resource before it is validated.
+	MutatorFunc func(res *Resource[APIVersionKind]) error
+
+	// ValidatorFunc validates a resource.
+	ValidatorFunc func(res Resource[APIVersionKind]) error
+
+	// ConversionFunc converts the spec of a resource to another APIVersion of its kind.
+	ConversionFunc func(in APIVersionKind) (APIVersionKind, error)
+
+	// Registration describes an APIVersionKind and the hooks run on its resources.
+	Registration struct {
+		// Factory instantiates the zero-valued spec of the APIVersionKind.
+		Factory AVKFunc
+		// Names are the names the kind can be referred to with. The singular and plural names
+		// default to the names derived from the kind.
+		Names ResourceNames
+		// Defaulters set the default values of resources. They run when resources are decoded and
+		// before they are written.
+		Defaulters []DefaulterFunc
+		// Mutators modify resources before they are validated.
+		Mutators []MutatorFunc
+		// Validators validate resources before they are written.
+		Validators []ValidatorFunc
+
+		// Hub is the APIVersion of the hub of the kind, i.e. its preferred version. It is empty if
+		// the APIVersionKind is the hub. Spokes are converted to the hub when they are read, and
+		// resources are always written with the hub.
+		Hub APIVersion
+		// ConvertToHub converts a spec of the APIVersionKind to the hub. It is required for spokes.
+		ConvertToHub ConversionFunc
+		// ConvertFromHub converts a spec of the hub to the APIVersionKind. It is required for
+		// spokes.
+		ConvertFromHub ConversionFunc
+	}
+)
+
 // Encoding is the encoding of a resource.
 type Encoding string
 
@@ -128,8 +291,16 @@ const (
 	JSONEncoding Encoding = "json"
 	// YAMLEncoding is the YAML encoding.
 	YAMLEncoding Encoding = "yaml"
+	// TOMLEncoding is the TOML encoding.
+	TOMLEncoding Encoding = "toml"
+	// JSONCEncoding is the encoding of JSON with comments and trailing commas. Other JSON5
+	// extensions are not supported.
+	JSONCEncoding Encoding = "jsonc"
 )
 
+// Encodings lists the supported encodings.
+var Encodings = []Encoding{JSONEncoding, JSONCEncoding, TOMLEncoding, YAMLEncoding}
+
 type (
 	// APIVersion is the API version of a resource.
 	APIVersion = string
@@ -144,10 +315,17 @@ func NewAPIVersion(s string) APIVersion {
 
 // Metadata is the metadata of a resource.
 type Metadata struct {
+	// Annotations is an unstructured key-value map attached to the resource.
 	Annotations map[string]string `json:"annotations,omitempty"`
-	Labels      map[string]string `json:"labels,omitempty"`
-	Name        string            `json:"name"`
-	Namespace   string            `json:"namespace,omitempty"`
+	// Labels is a key-value map used to organize resources.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Name is the name of the resource. It must be unique per kind within a namespace.
+	Name string `json:"name"`
+	// Namespace is the namespace of the resource. Defaults to "default".
+	Namespace string `json:"namespace,omitempty"`
+	// ResourceVersion is an opaque value set by the storage on every write.
+	// It is used to detect concurrent modifications of a resource.
+	ResourceVersion string `json:"resourceVersion,omitempty"`
 }
 
 // NewMetadata returns a new Metadata.
@@ -160,10 +338,55 @@ func NewMetadata(name, namespace string) Metadata {
 
 // Resource is a generic resource.
 type Resource[T any] struct {
+	// APIVersion is the versioned API group of the resource, e.g. "vib.amahdha.com/v1alpha1".
 	APIVersion APIVersion `json:"apiVersion"`
-	Kind       Kind       `json:"kind"`
-	Metadata   Metadata   `json:"metadata"`
-	Spec       T          `json:"spec"`
+	// Kind is the kind of the resource, e.g. "Profile".
+	Kind Kind `json:"kind"`
+	// Metadata is the metadata of the resource.
+	Metadata Metadata `json:"metadata"`
+	// Spec is the desired state of the resource.
+	Spec T `json:"spec"`
+
+	// node is the YAML document the resource was decoded from, if any. It is used to preserve
+	// comments and formatting when the resource is encoded again.
+	node *yaml.Node
+}
+
+// Node returns the YAML document the resource was decoded from, or nil.
+func (r Resource[T]) Node() *yaml.Node {
+	return r.node
+}
+
+// SetNode sets the YAML document the resource was decoded from.
+func (r *Resource[T]) SetNode(node *yaml.Node) {
+	r.node = node
+}
+
+const (
+	// ListAPIVersion is the apiVersion of a List.
+	ListAPIVersion = "v1"
+	// ListKind is the kind of a List.
+	ListKind = "List"
+)
+
+// List is a list of resources. It is used to encode many resources as a single document.
+type List[T any] struct {
+	APIVersion APIVersion    `json:"apiVersion"`
+	Kind       Kind          `json:"kind"`
+	Items      []Resource[T] `json:"items"`
+}
+
+// NewList returns a new List of the given resources.
+func NewList[T any](items []Resource[T]) List[T] {
+	if items == nil {
+		items = make([]Resource[T], 0)
+	}
+
+	return List[T]{
+		APIVersion: ListAPIVersion,
+		Kind:       ListKind,
+		Items:      items,
+	}
 }
 
 type avk struct {
@@ -138,6 +138,46 @@ func ValidateNamespace(s string) error {
 	return nil
 }
 
+// ValidateReferences ensures the resources referenced by resources exist, either in resources or in
+// storage.
+func ValidateReferences(storage Storage, resources []Resource[APIVersionKind]) error {
+	batch := make(map[Reference]struct{}, len(resources))
+	for _, res := range resources {
+		batch[NewReferenceFromResource(res)] = struct{}{}
+	}
+
+	var errs error
+	for _, res := range resources {
+		lister, ok := res.Spec.(ReferenceLister)
+		if !ok {
+			continue
+		}
+
+		for _, ref := range lister.References() {
+			if _, ok := batch[ref]; ok {
+				continue
+			}
+
+			_, err := storage.Get(NewAPIVersionKind(ref.APIVersion, ref.Kind), ref.NamespacedName)
+			if errors.Is(err, ErrNotFound) {
+				errs = flaterrors.Join(errs, fmt.Errorf(
+					"%s references %s which does not exist",
+					NewReferenceFromResource(res),
+					ref,
+				))
+			} else if err != nil {
+				return err
+			}
+		}
+	}
+
+	if errs != nil {
+		return flaterrors.Join(errs, ErrRef)
+	}
+
+	return nil
+}
+
 func validateSpecIfApplicable(v any) error {
 	valider, ok := v.(Validator)
 	if !ok {
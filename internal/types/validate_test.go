@@ -0,0 +1,89 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types_test
+
+import (
+	"testing"
+
+	codecadapter "github.com/alexandremahdhaoui/vib/internal/adapter/codec"
+	storageadapter "github.com/alexandremahdhaoui/vib/internal/adapter/storage"
+	"github.com/alexandremahdhaoui/vib/internal/service"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+	"github.com/alexandremahdhaoui/vib/pkg/apis/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateReferences(t *testing.T) {
+	apiServer := service.NewAPIServer()
+	v1alpha1.RegisterWithManager(apiServer)
+
+	storage, err := storageadapter.NewFilesystem(
+		apiServer,
+		codecadapter.NewYAML(),
+		codecadapter.NewDynamicResourceDecoder(apiServer),
+		t.TempDir(),
+		0,
+	)
+	assert.NoError(t, err)
+
+	resolver := v1alpha1.NewPlainResolver()
+	resolver.Metadata.Namespace = types.VibSystemNamespace
+	assert.NoError(t, storage.Create(resolver))
+
+	newExpressionSet := func(name, resolverName string) types.Resource[types.APIVersionKind] {
+		return types.Resource[types.APIVersionKind]{
+			APIVersion: v1alpha1.APIVersion,
+			Kind:       v1alpha1.ExpressionSetKind,
+			Metadata:   types.Metadata{Name: name, Namespace: types.DefaultNamespace},
+			Spec: &v1alpha1.ExpressionSetSpec{
+				ResolverRef: types.NamespacedName{Name: resolverName, Namespace: types.VibSystemNamespace},
+			},
+		}
+	}
+
+	profile := types.Resource[types.APIVersionKind]{
+		APIVersion: v1alpha1.APIVersion,
+		Kind:       v1alpha1.ProfileKind,
+		Metadata:   types.Metadata{Name: "profile", Namespace: types.DefaultNamespace},
+		Spec:       &v1alpha1.ProfileSpec{Refs: []types.NamespacedName{{Name: "es"}}},
+	}
+
+	t.Run("Stored", func(t *testing.T) {
+		err := types.ValidateReferences(storage, []types.Resource[types.APIVersionKind]{
+			newExpressionSet("es", v1alpha1.PlainResolverRef),
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Batch", func(t *testing.T) {
+		err := types.ValidateReferences(storage, []types.Resource[types.APIVersionKind]{
+			profile,
+			newExpressionSet("es", v1alpha1.PlainResolverRef),
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Dangling", func(t *testing.T) {
+		err := types.ValidateReferences(storage, []types.Resource[types.APIVersionKind]{
+			profile,
+			newExpressionSet("other", "unknown"),
+		})
+		assert.ErrorIs(t, err, types.ErrRef)
+		assert.ErrorContains(t, err, `ExpressionSet "es" in namespace "default"`)
+		assert.ErrorContains(t, err, `Resolver "unknown" in namespace "vib-system"`)
+	})
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by gendocs. DO NOT EDIT.
+
+package types
+
+func init() {
+	RegisterDocs("github.com/alexandremahdhaoui/vib/internal/types", map[string]string{
+		"APIResource":                 "APIResource is the discovery data of a registered kind.",
+		"APIResource.Namespaced":      "Namespaced is true if the resources of the kind are stored in namespaces.",
+		"APIServer":                   "APIServer is the interface that defines the methods for an API server.",
+		"APIVersion":                  "APIVersion is the API version of a resource.",
+		"APIVersionKind":              "APIVersionKind is the interface that defines the methods for an API version and kind.",
+		"AVKFunc":                     "AVKFunc is a function that returns an APIVersionKind.",
+		"AdmissionHook":               "AdmissionHook is the interface implemented by hooks reviewing the operations performed on resources before they are persisted.",
+		"AdmissionRequest":            "AdmissionRequest describes an operation performed on a resource.",
+		"AdmissionRequest.Object":     "Object is the resource being created or updated, or the resource being deleted.",
+		"AdmissionRequest.Operation":  "Operation is the operation performed on the resource.",
+		"AdmissionResponse":           "AdmissionResponse is the decision of an admission hook.",
+		"AdmissionResponse.Allowed":   "Allowed is true if the operation is allowed.",
+		"AdmissionResponse.Messages":  "Messages explain why the operation is denied, or are printed as warnings if it is allowed.",
+		"AdmissionResponse.Patch":     "Patch is a JSON Patch (RFC 6902) applied to the resource, if the operation is allowed. Patches are ignored when resources are deleted.",
+		"AdmissionReview":             "AdmissionReview is the document exchanged with admission hooks. Hooks receive a review holding a request, and answer with a review holding a response.",
+		"Codec":                       "Codec is the interface that defines the methods for a codec.",
+		"ConversionFunc":              "ConversionFunc converts the spec of a resource to another APIVersion of its kind.",
+		"DecodeError":                 "DecodeError is returned when a document cannot be decoded. It locates the error in the input.",
+		"DecodeError.Document":        "Document is the index of the document in the input.",
+		"DecodeError.Err":             "Err is the underlying error.",
+		"DecodeError.File":            "File is the name of the decoded file, if known.",
+		"DecodeError.Line":            "Line and Column locate the error in the input. They are 0 if unknown.",
+		"DecodeError.Path":            "Path is the path of the erroneous field, e.g. \"spec.resolverRef.namespace\".",
+		"DefaulterFunc":               "DefaulterFunc sets the default values of a resource.",
+		"DynamicDecoder":              "DynamicDecoder is the interface that defines the methods for a dynamic decoder.",
+		"Encoding":                    "Encoding is the encoding of a resource.",
+		"EncodingMigrator":            "EncodingMigrator is the interface implemented by storages able to rewrite the resources stored with another encoding than their own.",
+		"HistoryStorage":              "HistoryStorage is the interface implemented by storages that keep the previous versions of resources.",
+		"Kind":                        "Kind is the kind of a resource.",
+		"List":                        "List is a list of resources. It is used to encode many resources as a single document.",
+		"Metadata":                    "Metadata is the metadata of a resource.",
+		"Metadata.Annotations":        "Annotations is an unstructured key-value map attached to the resource.",
+		"Metadata.Labels":             "Labels is a key-value map used to organize resources.",
+		"Metadata.Name":               "Name is the name of the resource. It must be unique per kind within a namespace.",
+		"Metadata.Namespace":          "Namespace is the namespace of the resource. Defaults to \"default\".",
+		"Metadata.ResourceVersion":    "ResourceVersion is an opaque value set by the storage on every write. It is used to detect concurrent modifications of a resource.",
+		"Migration":                   "Migration describes a resource whose encoding was migrated.",
+		"Migration.Filename":          "Filename is the name of the migrated file.",
+		"Migration.From":              "From is the previous encoding of the resource.",
+		"Migration.Namespace":         "Namespace is the namespace of the resource.",
+		"Migration.To":                "To is the new encoding of the resource.",
+		"MutatorFunc":                 "MutatorFunc modifies a resource before it is validated.",
+		"NamespaceLister":             "NamespaceLister is the interface implemented by storages able to list their namespaces.",
+		"NamespacedName":              "NamespacedName is a namespaced name.",
+		"NamespacedName.Name":         "Name is the name of the referenced resource.",
+		"NamespacedName.Namespace":    "Namespace is the namespace of the referenced resource. Defaults to \"default\".",
+		"Operation":                   "Operation is an operation performed on a resource.",
+		"PatchOperation":              "PatchOperation is an operation of a JSON Patch (RFC 6902).",
+		"PatchOperation.From":         "From is a JSON Pointer to the source location of \"move\" and \"copy\" operations.",
+		"PatchOperation.Op":           "Op is one of \"add\", \"remove\", \"replace\", \"move\", \"copy\" or \"test\".",
+		"PatchOperation.Path":         "Path is a JSON Pointer (RFC 6901) to the target location.",
+		"PatchOperation.Value":        "Value is the value of \"add\", \"replace\" and \"test\" operations.",
+		"Reader":                      "Reader is the interface that defines the methods for a reader.",
+		"Reference":                   "Reference is a reference to a resource.",
+		"ReferenceIndex":              "ReferenceIndex is a reverse-reference index: it maps resources to the resources referencing them.",
+		"ReferenceLister":             "ReferenceLister is the interface implemented by specs referencing other resources.",
+		"Registration":                "Registration describes an APIVersionKind and the hooks run on its resources.",
+		"Registration.ConvertFromHub": "ConvertFromHub converts a spec of the hub to the APIVersionKind. It is required for spokes.",
+		"Registration.ConvertToHub":   "ConvertToHub converts a spec of the APIVersionKind to the hub. It is required for spokes.",
+		"Registration.Defaulters":     "Defaulters set the default values of resources. They run when resources are decoded and before they are written.",
+		"Registration.Factory":        "Factory instantiates the zero-valued spec of the APIVersionKind.",
+		"Registration.Hub":            "Hub is the APIVersion of the hub of the kind, i.e. its preferred version. It is empty if the APIVersionKind is the hub. Spokes are converted to the hub when they are read, and resources are always written with the hub.",
+		"Registration.Mutators":       "Mutators modify resources before they are validated.",
+		"Registration.Names":          "Names are the names the kind can be referred to with. The singular and plural names default to the names derived from the kind.",
+		"Registration.Validators":     "Validators validate resources before they are written.",
+		"Renderer":                    "Renderer is the interface that defines the methods for a renderer.",
+		"Resource":                    "Resource is a generic resource.",
+		"Resource.APIVersion":         "APIVersion is the versioned API group of the resource, e.g. \"vib.amahdha.com/v1alpha1\".",
+		"Resource.Kind":               "Kind is the kind of the resource, e.g. \"Profile\".",
+		"Resource.Metadata":           "Metadata is the metadata of the resource.",
+		"Resource.Spec":               "Spec is the desired state of the resource.",
+		"ResourceNames":               "ResourceNames are the names a kind can be referred to with, e.g. \"vib get es\".",
+		"ResourceNames.Plural":        "Plural is the lowercase plural name of the kind, e.g. \"expressionsets\".",
+		"ResourceNames.ShortNames":    "ShortNames are the short lowercase aliases of the kind, e.g. \"es\".",
+		"ResourceNames.Singular":      "Singular is the lowercase singular name of the kind, e.g. \"expressionset\".",
+		"Revision":                    "Revision describes a previous version of a resource.",
+		"Revision.ResourceVersion":    "ResourceVersion is the resourceVersion the resource had at this revision.",
+		"Revision.Revision":           "Revision is the number identifying the revision. Revision numbers are increasing.",
+		"Revision.Timestamp":          "Timestamp is the time at which this version of the resource was written.",
+		"Schema":                      "Schema is a JSON Schema.",
+		"SchemaExtender":              "SchemaExtender can be implemented by API types to express constraints of their schema that cannot be inferred from their Go type, e.g. a one-of between fields.",
+		"SchemaField":                 "SchemaField is a field of a struct, as seen in its JSON representation.",
+		"SchemaProvider":              "SchemaProvider can be implemented by API types whose schema is not defined by their Go type, e.g. the specs of kinds defined at runtime.",
+		"Storage":                     "Storage is the interface that defines the methods for a storage.",
+		"Validator":                   "Validator is the interface that defines the methods for a validator.",
+		"ValidatorFunc":               "ValidatorFunc validates a resource.",
+	})
+}
@@ -16,6 +16,8 @@ limitations under the License.
 
 package v1alpha1
 
+//go:generate go run ../../../hack/gendocs
+
 import "github.com/alexandremahdhaoui/vib/internal/types"
 
 const (
@@ -24,15 +26,51 @@ const (
 	ResolverKind      types.Kind = "Resolver"
 	ProfileKind       types.Kind = "Profile"
 
+	ResourceDefinitionKind types.Kind = "ResourceDefinition"
+
 	APIVersion types.APIVersion = "vib.amahdha.com/v1alpha1"
 )
 
 // RegisterWithManager registers the APIVersionKinds of this package with the given manager.
 // It allows the manager to discover and manage the resources defined in this API group version.
+// Kinds of the LegacyAPIVersion are registered as spokes of the kinds of the APIVersion.
+// It panics if the kinds of this package are already registered.
 func RegisterWithManager(mgr types.APIServer) {
-	mgr.Register([]types.AVKFunc{
-		func() types.APIVersionKind { return &ExpressionSetSpec{} },
-		func() types.APIVersionKind { return &ResolverSpec{} },
-		func() types.APIVersionKind { return &ProfileSpec{} },
+	err := mgr.Register([]types.Registration{
+		{
+			Factory:    func() types.APIVersionKind { return &ExpressionSetSpec{} },
+			Names:      types.ResourceNames{ShortNames: []string{"es"}},
+			Defaulters: []types.DefaulterFunc{DefaultExpressionSet},
+			Validators: []types.ValidatorFunc{ValidateExpressionSet},
+		},
+		{
+			Factory:    func() types.APIVersionKind { return &ResolverSpec{} },
+			Names:      types.ResourceNames{ShortNames: []string{"rs"}},
+			Validators: []types.ValidatorFunc{ValidateResolver},
+		},
+		{
+			Factory:    func() types.APIVersionKind { return &ProfileSpec{} },
+			Names:      types.ResourceNames{ShortNames: []string{"pf"}},
+			Defaulters: []types.DefaulterFunc{DefaultProfile},
+			Validators: []types.ValidatorFunc{ValidateProfile},
+		},
+		{
+			Factory:    func() types.APIVersionKind { return &ResourceDefinitionSpec{} },
+			Names:      types.ResourceNames{ShortNames: []string{"rd"}},
+			Defaulters: []types.DefaulterFunc{DefaultResourceDefinition},
+			Validators: []types.ValidatorFunc{
+				ValidateResourceDefinition,
+				NewResourceDefinitionConflictValidator(mgr),
+			},
+		},
+		{
+			Factory:        func() types.APIVersionKind { return &LegacyExpressionSetSpec{} },
+			Hub:            APIVersion,
+			ConvertToHub:   ConvertLegacyExpressionSetToHub,
+			ConvertFromHub: ConvertLegacyExpressionSetFromHub,
+		},
 	})
+	if err != nil {
+		panic(err)
+	}
 }
@@ -17,19 +17,25 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"errors"
+
+	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
+
 	"github.com/alexandremahdhaoui/vib/internal/types"
 	"github.com/alexandremahdhaoui/vib/internal/util"
 )
 
+var _ types.ReferenceLister = ExpressionSetSpec{}
+
 // ExpressionSetSpec defines the desired state of an ExpressionSet.
 // It contains a set of expressions that can be rendered into a desired output and referenced in a profile.
 type ExpressionSetSpec struct {
 	// ArbitraryKeys is used for special resolvers, such as "plain", that do not require associated values.
 	// ArbitraryKeys are always rendered before KeyValues.
-	ArbitraryKeys []string `json:"arbitraryKeys"`
+	ArbitraryKeys []string `json:"arbitraryKeys,omitempty"`
 
 	// KeyValues uses a list of maps to avoid reordered key-values.
-	KeyValues []map[string]string `json:"keyValues"`
+	KeyValues []map[string]string `json:"keyValues,omitempty"`
 
 	// ResolverRef is a reference to the Resolver that should be used to render this ExpressionSet.
 	ResolverRef types.NamespacedName `json:"resolverRef"`
@@ -47,17 +53,32 @@ func (e ExpressionSetSpec) Kind() types.Kind {
 	return ExpressionSetKind
 }
 
+// References returns the Resolver referenced by the ExpressionSetSpec, if set.
+// It implements the types.ReferenceLister interface.
+func (e ExpressionSetSpec) References() []types.Reference {
+	if e.ResolverRef.Name == "" {
+		return nil
+	}
+
+	return []types.Reference{{
+		APIVersion:     APIVersion,
+		Kind:           ResolverKind,
+		NamespacedName: defaultRef(e.ResolverRef),
+	}}
+}
+
 // Render renders the ExpressionSetSpec using the specified storage to resolve a resolver.
 // It implements the types.Renderer interface.
 func (e *ExpressionSetSpec) Render(storage types.Storage) (string, error) {
-	resolverRef := defaultRef(e.ResolverRef)
-	if err := types.ValidateNamespacedName(resolverRef); err != nil {
-		return "", err
+	refs := e.References()
+	if len(refs) == 0 {
+		return "", flaterrors.Join(types.ErrVal, errors.New("resolverRef must be set"))
 	}
 
+	resolverRef := refs[0].NamespacedName
 	resolver, err := types.GetTypedResourceFromStorage(
 		storage,
-		e.ResolverRef,
+		resolverRef,
 		&ResolverSpec{},
 	)
 	if err != nil {
@@ -87,10 +108,3 @@ func (e *ExpressionSetSpec) Render(storage types.Storage) (string, error) {
 
 	return buf, nil
 }
-
-func defaultRef(nsName types.NamespacedName) types.NamespacedName {
-	if nsName.Namespace == "" {
-		nsName.Namespace = types.DefaultNamespace
-	}
-	return nsName
-}
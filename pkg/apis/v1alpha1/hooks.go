@@ -0,0 +1,245 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
+
+	"github.com/alexandremahdhaoui/vib/internal/types"
+)
+
+// DefaultExpressionSet defaults the namespace of the resolverRef of an ExpressionSet.
+// It implements the types.DefaulterFunc type.
+func DefaultExpressionSet(res *types.Resource[types.APIVersionKind]) {
+	spec, ok := res.Spec.(*ExpressionSetSpec)
+	if !ok || spec.ResolverRef.Name == "" {
+		return
+	}
+
+	spec.ResolverRef = defaultRef(spec.ResolverRef)
+}
+
+// DefaultProfile defaults the namespace of the refs of a Profile.
+// It implements the types.DefaulterFunc type.
+func DefaultProfile(res *types.Resource[types.APIVersionKind]) {
+	spec, ok := res.Spec.(*ProfileSpec)
+	if !ok {
+		return
+	}
+
+	for i, ref := range spec.Refs {
+		spec.Refs[i] = defaultRef(ref)
+	}
+}
+
+// DefaultResourceDefinition defaults the namespace of the resolverRef of a ResourceDefinition.
+// It implements the types.DefaulterFunc type.
+func DefaultResourceDefinition(res *types.Resource[types.APIVersionKind]) {
+	spec, ok := res.Spec.(*ResourceDefinitionSpec)
+	if !ok || spec.ResolverRef.Name == "" {
+		return
+	}
+
+	spec.ResolverRef = defaultRef(spec.ResolverRef)
+}
+
+// ValidateExpressionSet validates the resolverRef of an ExpressionSet, if set.
+// It implements the types.ValidatorFunc type.
+func ValidateExpressionSet(res types.Resource[types.APIVersionKind]) error {
+	for _, ref := range specReferences(res.Spec) {
+		if err := types.ValidateNamespacedName(ref.NamespacedName); err != nil {
+			return flaterrors.Join(err, errors.New("invalid resolverRef"))
+		}
+	}
+
+	return nil
+}
+
+// ValidateProfile validates the refs of a Profile, and ensures they are not duplicated.
+// It implements the types.ValidatorFunc type.
+func ValidateProfile(res types.Resource[types.APIVersionKind]) error {
+	var errs error
+
+	seen := make(map[types.NamespacedName]struct{})
+	for _, ref := range specReferences(res.Spec) {
+		if err := types.ValidateNamespacedName(ref.NamespacedName); err != nil {
+			errs = flaterrors.Join(errs, err)
+			continue
+		}
+
+		if _, ok := seen[ref.NamespacedName]; ok {
+			errs = flaterrors.Join(errs, types.ErrVal, fmt.Errorf(
+				"ExpressionSet %q in namespace %q is referenced more than once",
+				ref.NamespacedName.Name,
+				ref.NamespacedName.Namespace,
+			))
+		}
+
+		seen[ref.NamespacedName] = struct{}{}
+	}
+
+	return errs
+}
+
+// ValidateResolver ensures the configuration of a Resolver matches its type.
+// It implements the types.ValidatorFunc type.
+func ValidateResolver(res types.Resource[types.APIVersionKind]) error {
+	var spec ResolverSpec
+	switch v := res.Spec.(type) {
+	case *ResolverSpec:
+		spec = *v
+	case ResolverSpec:
+		spec = v
+	default:
+		return nil
+	}
+
+	if err := validateResolverSpec(spec); err != nil {
+		return flaterrors.Join(types.ErrVal, err)
+	}
+
+	return nil
+}
+
+// ValidateResourceDefinition validates the apiVersion and the kind defined by a ResourceDefinition.
+// Kinds cannot be defined in the API groups of vib, including the group of the LegacyAPIVersion.
+// It implements the types.ValidatorFunc type.
+func ValidateResourceDefinition(res types.Resource[types.APIVersionKind]) error {
+	var spec ResourceDefinitionSpec
+	switch v := res.Spec.(type) {
+	case *ResourceDefinitionSpec:
+		spec = *v
+	case ResourceDefinitionSpec:
+		spec = v
+	default:
+		return nil
+	}
+
+	errs := flaterrors.Join(
+		types.ValidateAPIVersion(spec.DefinedAPIVersion()),
+		types.ValidateKind(spec.Names.Kind),
+	)
+
+	if kind := spec.Names.Kind; kind != "" && !unicode.IsUpper(rune(kind[0])) {
+		errs = flaterrors.Join(errs, types.ErrVal, fmt.Errorf("Kind %q must start with an uppercase letter", kind))
+	}
+
+	names := append([]string{spec.Names.Singular, spec.Names.Plural}, spec.Names.ShortNames...)
+	for _, name := range names {
+		if name != "" && !types.LoweredKindRegex.MatchString(name) {
+			errs = flaterrors.Join(errs, types.ErrVal, fmt.Errorf("name %q must only contain lowercase letters", name))
+		}
+	}
+
+	for _, apiVersion := range []types.APIVersion{APIVersion, LegacyAPIVersion} {
+		if group, _, _ := strings.Cut(apiVersion, "/"); strings.EqualFold(spec.Group, group) {
+			errs = flaterrors.Join(errs, types.ErrVal, fmt.Errorf("group %q is reserved", group))
+		}
+	}
+
+	if spec.Schema != nil && spec.Schema.Type != "" && spec.Schema.Type != "object" {
+		errs = flaterrors.Join(errs, types.ErrVal, errors.New(`the type of the schema must be "object"`))
+	}
+
+	for _, ref := range spec.References() {
+		if err := types.ValidateNamespacedName(ref.NamespacedName); err != nil {
+			errs = flaterrors.Join(errs, err, errors.New("invalid resolverRef"))
+		}
+	}
+
+	return errs
+}
+
+// NewResourceDefinitionConflictValidator returns a validator rejecting ResourceDefinitions defining
+// a kind that is already registered with mgr, unless it is defined by the same ResourceDefinition,
+// or whose names are used by another registered kind.
+// It implements the types.ValidatorFunc type.
+func NewResourceDefinitionConflictValidator(mgr types.APIServer) types.ValidatorFunc {
+	return func(res types.Resource[types.APIVersionKind]) error {
+		var spec ResourceDefinitionSpec
+		switch v := res.Spec.(type) {
+		case *ResourceDefinitionSpec:
+			spec = *v
+		case ResourceDefinitionSpec:
+			spec = v
+		default:
+			return nil
+		}
+
+		avk := types.NewAPIVersionKind(spec.DefinedAPIVersion(), spec.Names.Kind)
+		if registered, err := mgr.Get(avk); err == nil {
+			if custom, ok := registered.Spec.(*CustomSpec); !ok ||
+				custom.definitionRef != types.NewReferenceFromResource(res) {
+				return flaterrors.Join(types.ErrVal, types.ErrExists, fmt.Errorf(
+					"apiVersion %q kind %q is already registered",
+					registered.APIVersion,
+					registered.Kind,
+				))
+			}
+		}
+
+		names := types.NewResourceNames(spec.Names.Kind, types.ResourceNames{
+			Singular:   spec.Names.Singular,
+			Plural:     spec.Names.Plural,
+			ShortNames: spec.Names.ShortNames,
+		})
+
+		var errs error
+		for _, resource := range mgr.APIResources() {
+			if strings.EqualFold(resource.APIVersion, avk.APIVersion()) &&
+				strings.EqualFold(resource.Kind, avk.Kind()) {
+				continue
+			}
+
+			for _, name := range append([]string{names.Singular, names.Plural}, names.ShortNames...) {
+				if resource.Names.Matches(name) {
+					errs = flaterrors.Join(errs, types.ErrVal, types.ErrExists, fmt.Errorf(
+						"name %q is used by apiVersion %q kind %q",
+						name,
+						resource.APIVersion,
+						resource.Kind,
+					))
+				}
+			}
+		}
+
+		return errs
+	}
+}
+
+// specReferences returns the references of spec, if it implements the types.ReferenceLister
+// interface.
+func specReferences(spec types.APIVersionKind) []types.Reference {
+	lister, ok := spec.(types.ReferenceLister)
+	if !ok {
+		return nil
+	}
+
+	return lister.References()
+}
+
+func defaultRef(nsName types.NamespacedName) types.NamespacedName {
+	if nsName.Namespace == "" {
+		nsName.Namespace = types.DefaultNamespace
+	}
+	return nsName
+}
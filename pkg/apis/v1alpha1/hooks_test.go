@@ -0,0 +1,105 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1_test
+
+import (
+	"testing"
+
+	"github.com/alexandremahdhaoui/vib/internal/types"
+	"github.com/alexandremahdhaoui/vib/pkg/apis/v1alpha1"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultProfile(t *testing.T) {
+	res := types.Resource[types.APIVersionKind]{
+		Spec: &v1alpha1.ProfileSpec{Refs: []types.NamespacedName{
+			{Name: "es-0"},
+			{Name: "es-1", Namespace: "team-a"},
+		}},
+	}
+
+	v1alpha1.DefaultProfile(&res)
+
+	assert.Equal(t, []types.NamespacedName{
+		{Name: "es-0", Namespace: types.DefaultNamespace},
+		{Name: "es-1", Namespace: "team-a"},
+	}, res.Spec.(*v1alpha1.ProfileSpec).Refs)
+}
+
+func TestValidateProfile(t *testing.T) {
+	for _, tc := range []struct {
+		Name    string
+		Refs    []types.NamespacedName
+		WantErr bool
+	}{
+		{Name: "Valid", Refs: []types.NamespacedName{{Name: "es-0"}, {Name: "es-1"}}},
+		{Name: "InvalidName", Refs: []types.NamespacedName{{Name: "Es"}}, WantErr: true},
+		{
+			Name:    "Duplicate",
+			Refs:    []types.NamespacedName{{Name: "es-0"}, {Name: "es-0", Namespace: "default"}},
+			WantErr: true,
+		},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := v1alpha1.ValidateProfile(types.Resource[types.APIVersionKind]{
+				Spec: &v1alpha1.ProfileSpec{Refs: tc.Refs},
+			})
+
+			if tc.WantErr {
+				assert.ErrorIs(t, err, types.ErrVal)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateResolver(t *testing.T) {
+	assert.NoError(t, v1alpha1.ValidateResolver(v1alpha1.NewPlainResolver()))
+	assert.ErrorIs(t, v1alpha1.ValidateResolver(types.Resource[types.APIVersionKind]{
+		Spec: &v1alpha1.ResolverSpec{Type: v1alpha1.FmtResolverType},
+	}), types.ErrVal)
+}
+
+func TestValidateResourceDefinition(t *testing.T) {
+	for _, tc := range []struct {
+		Name    string
+		Group   string
+		WantErr bool
+	}{
+		{Name: "Valid", Group: "example.com"},
+		{Name: "ReservedGroup", Group: "vib.amahdha.com", WantErr: true},
+		{Name: "ReservedLegacyGroup", Group: "vib.alexandre.mahdhaoui.com", WantErr: true},
+	} {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := v1alpha1.ValidateResourceDefinition(types.Resource[types.APIVersionKind]{
+				Spec: &v1alpha1.ResourceDefinitionSpec{
+					Group:   tc.Group,
+					Version: "v1alpha1",
+					Names:   v1alpha1.ResourceDefinitionNames{Kind: "ExpressionSet"},
+				},
+			})
+
+			if tc.WantErr {
+				assert.ErrorIs(t, err, types.ErrVal)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
+
+	"github.com/alexandremahdhaoui/vib/internal/types"
+)
+
+// LegacyAPIVersion is the former APIVersion of this API group version. Its resources are converted
+// to APIVersion when they are read, and are written with APIVersion.
+const LegacyAPIVersion types.APIVersion = "vib.alexandre.mahdhaoui.com/v1alpha1"
+
+// LegacyExpressionSetSpec is the ExpressionSetSpec of the LegacyAPIVersion. It has the same fields as
+// the ExpressionSetSpec.
+type LegacyExpressionSetSpec ExpressionSetSpec
+
+// APIVersion returns the APIVersion of the LegacyExpressionSetSpec.
+// It implements the types.DefinedResource interface.
+func (e LegacyExpressionSetSpec) APIVersion() types.APIVersion {
+	return LegacyAPIVersion
+}
+
+// Kind returns the Kind of the LegacyExpressionSetSpec.
+// It implements the types.DefinedResource interface.
+func (e LegacyExpressionSetSpec) Kind() types.Kind {
+	return ExpressionSetKind
+}
+
+// ConvertLegacyExpressionSetToHub converts a *LegacyExpressionSetSpec to an *ExpressionSetSpec.
+func ConvertLegacyExpressionSetToHub(in types.APIVersionKind) (types.APIVersionKind, error) {
+	spec, ok := in.(*LegacyExpressionSetSpec)
+	if !ok {
+		return nil, flaterrors.Join(
+			types.ErrType,
+			fmt.Errorf("expected *LegacyExpressionSetSpec, got %T", in),
+		)
+	}
+
+	out := ExpressionSetSpec(*spec)
+
+	return &out, nil
+}
+
+// ConvertLegacyExpressionSetFromHub converts an *ExpressionSetSpec to a *LegacyExpressionSetSpec.
+func ConvertLegacyExpressionSetFromHub(in types.APIVersionKind) (types.APIVersionKind, error) {
+	spec, ok := in.(*ExpressionSetSpec)
+	if !ok {
+		return nil, flaterrors.Join(
+			types.ErrType,
+			fmt.Errorf("expected *ExpressionSetSpec, got %T", in),
+		)
+	}
+
+	out := LegacyExpressionSetSpec(*spec)
+
+	return &out, nil
+}
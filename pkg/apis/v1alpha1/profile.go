@@ -21,13 +21,14 @@ import (
 	"github.com/alexandremahdhaoui/vib/internal/util"
 )
 
+var _ types.ReferenceLister = ProfileSpec{}
+
 // ProfileSpec defines the desired state of a Profile.
 // It contains a list of references to ExpressionSets that should be rendered to form the profile.
 type ProfileSpec struct {
-	// Refs is a list of references to ExpressionSets.
-	// TODO: ExpressionRefs must be validated to ensure no duplication.
-	// Name duplication would yield unexpected behavior.
-	Refs []types.NamespacedName `json:"refs"`
+	// Refs is a list of references to ExpressionSets. An ExpressionSet must not be referenced more
+	// than once.
+	Refs []types.NamespacedName `json:"refs,omitempty"`
 }
 
 // APIVersion returns the APIVersion of the ProfileSpec.
@@ -42,22 +43,37 @@ func (p ProfileSpec) Kind() types.Kind {
 	return ProfileKind
 }
 
+// References returns the ExpressionSets referenced by the ProfileSpec.
+// It implements the types.ReferenceLister interface.
+func (p ProfileSpec) References() []types.Reference {
+	out := make([]types.Reference, 0, len(p.Refs))
+	for _, ref := range p.Refs {
+		out = append(out, types.Reference{
+			APIVersion:     APIVersion,
+			Kind:           ExpressionSetKind,
+			NamespacedName: defaultRef(ref),
+		})
+	}
+
+	return out
+}
+
 // Render renders the ProfileSpec by resolving and rendering each of the referenced ExpressionSets.
 // It implements the types.Renderer interface.
 func (p *ProfileSpec) Render(storage types.Storage) (string, error) {
-	refList := make([]types.NamespacedName, len(p.Refs))
-	refMap := make(map[types.NamespacedName]string, len(p.Refs))
+	refs := p.References()
+	refList := make([]types.NamespacedName, len(refs))
+	refMap := make(map[types.NamespacedName]string, len(refs))
 
 	namespaces := make(map[string]struct{})
-	for i, ref := range p.Refs {
-		defaultedRef := defaultRef(ref)
-		if err := types.ValidateNamespacedName(defaultedRef); err != nil {
+	for i, ref := range refs {
+		if err := types.ValidateNamespacedName(ref.NamespacedName); err != nil {
 			return "", err
 		}
 
-		refList[i] = defaultedRef
-		refMap[defaultedRef] = ""
-		namespaces[defaultedRef.Namespace] = struct{}{}
+		refList[i] = ref.NamespacedName
+		refMap[ref.NamespacedName] = ""
+		namespaces[ref.NamespacedName.Namespace] = struct{}{}
 	}
 
 	// -- Expression sets
@@ -29,6 +29,7 @@ import (
 
 var (
 	_ types.APIVersionKind = &ResolverSpec{}
+	_ types.SchemaExtender = &ResolverSpec{}
 	_ Resolver             = &ResolverSpec{}
 
 	_ Resolver = &ExecResolverSpec{}
@@ -129,6 +130,24 @@ func (r ResolverSpec) Resolve(key string, value string) (string, error) {
 	}
 }
 
+// ExtendSchema implements the types.SchemaExtender interface.
+// Exactly one resolver configuration must be set, matching the type of the resolver.
+func (r *ResolverSpec) ExtendSchema(schema *types.Schema) {
+	typeSchema := schema.Properties["type"]
+	for _, resolverType := range []struct{ name, field string }{
+		{ExecResolverType, "exec"},
+		{FmtResolverType, "fmt"},
+		{PlainResolverType, "plain"},
+		{GotemplateResolverType, "gotemplate"},
+	} {
+		typeSchema.Enum = append(typeSchema.Enum, resolverType.name)
+		schema.OneOf = append(schema.OneOf, &types.Schema{
+			Properties: map[string]*types.Schema{"type": {Const: resolverType.name}},
+			Required:   []string{"type", resolverType.field},
+		})
+	}
+}
+
 type (
 	// ExecResolverSpec defines the configuration for an exec resolver.
 	ExecResolverSpec struct {
@@ -146,7 +165,7 @@ type (
 		// Template is the fmt template string.
 		Template string `json:"template"`
 		// FmtArguments is a list of FmtArgument, that will be used to format the template.
-		FmtArguments []FmtArgument `json:"fmtArguments"`
+		FmtArguments []FmtArgument `json:"fmtArguments,omitempty"`
 	}
 
 	// GotemplateResolverSpec defines the configuration for a go-template resolver.
@@ -93,3 +93,25 @@ func Must[T any](t *testing.T, f func() (T, error)) T {
 	assert.NoError(t, err)
 	return out
 }
+
+func TestResolverSpec_ExtendSchema(t *testing.T) {
+	schema := types.NewResourceSchema(&v1alpha1.ResolverSpec{})
+	spec := schema.Properties["spec"]
+
+	assert.Equal(t, []string{"type"}, spec.Required)
+	assert.Len(t, spec.OneOf, 4)
+
+	// -- each resolver type requires its own configuration.
+	for _, branch := range spec.OneOf {
+		resolverType := branch.Properties["type"].Const
+		assert.Contains(t, spec.Properties["type"].Enum, resolverType)
+		assert.Contains(t, spec.Properties, branch.Required[1])
+		assert.Equal(t, "type", branch.Required[0])
+	}
+
+	assert.Equal(t, v1alpha1.ResolverKind, schema.Properties["kind"].Const)
+	assert.Equal(t, false, spec.AdditionalProperties)
+
+	// -- descriptions are generated from the doc comments of the types.
+	assert.Equal(t, "Type is the type of the resolver.", spec.Properties["type"].Description)
+}
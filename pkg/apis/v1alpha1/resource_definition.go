@@ -0,0 +1,253 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/alexandremahdhaoui/tooling/pkg/flaterrors"
+
+	"github.com/alexandremahdhaoui/vib/internal/types"
+	"github.com/alexandremahdhaoui/vib/internal/util"
+)
+
+var (
+	_ types.ReferenceLister = ResourceDefinitionSpec{}
+
+	_ types.ReferenceLister = &CustomSpec{}
+	_ types.Renderer        = &CustomSpec{}
+	_ types.SchemaProvider  = &CustomSpec{}
+)
+
+// ResourceDefinitionSpec defines the desired state of a ResourceDefinition.
+// It declares a new kind of resources, whose spec is described by a JSON Schema.
+type ResourceDefinitionSpec struct {
+	// Group is the API group of the defined kind, e.g. "example.com".
+	Group string `json:"group"`
+	// Version is the version of the defined kind, e.g. "v1".
+	Version string `json:"version"`
+	// Names are the names of the defined kind.
+	Names ResourceDefinitionNames `json:"names"`
+	// Schema is the JSON Schema of the spec of the defined resources. Specs are only required to be
+	// objects if it is not set.
+	Schema *types.Schema `json:"schema,omitempty"`
+	// ResolverRef is a reference to the Resolver used to render the defined resources. Each
+	// top-level field of their spec is resolved as a key-value, sorted by key.
+	ResolverRef types.NamespacedName `json:"resolverRef"`
+}
+
+// ResourceDefinitionNames are the names of a kind defined by a ResourceDefinition.
+type ResourceDefinitionNames struct {
+	// Kind is the kind of the defined resources, e.g. "SSHHost".
+	Kind string `json:"kind"`
+	// Singular is the lowercase singular name of the kind. Defaults to the lowercase kind.
+	Singular string `json:"singular,omitempty"`
+	// Plural is the lowercase plural name of the kind, e.g. "sshhosts". Defaults to the plural of
+	// the singular name.
+	Plural string `json:"plural,omitempty"`
+	// ShortNames are short lowercase aliases of the kind, e.g. "ssh".
+	ShortNames []string `json:"shortNames,omitempty"`
+}
+
+// APIVersion returns the APIVersion of the ResourceDefinitionSpec.
+// It implements the types.DefinedResource interface.
+func (r ResourceDefinitionSpec) APIVersion() types.APIVersion {
+	return APIVersion
+}
+
+// Kind returns the Kind of the ResourceDefinitionSpec.
+// It implements the types.DefinedResource interface.
+func (r ResourceDefinitionSpec) Kind() types.Kind {
+	return ResourceDefinitionKind
+}
+
+// DefinedAPIVersion returns the APIVersion of the defined kind.
+func (r ResourceDefinitionSpec) DefinedAPIVersion() types.APIVersion {
+	return types.NewAPIVersion(r.Group + "/" + r.Version)
+}
+
+// References returns the Resolver referenced by the ResourceDefinitionSpec, if set.
+// It implements the types.ReferenceLister interface.
+func (r ResourceDefinitionSpec) References() []types.Reference {
+	if r.ResolverRef.Name == "" {
+		return nil
+	}
+
+	return []types.Reference{{
+		APIVersion:     APIVersion,
+		Kind:           ResolverKind,
+		NamespacedName: defaultRef(r.ResolverRef),
+	}}
+}
+
+// NewCustomRegistration returns the registration of the kind defined by a ResourceDefinition. Its
+// specs are validated against the schema of the definition, and its defaults are applied.
+func NewCustomRegistration(definition types.Resource[*ResourceDefinitionSpec]) types.Registration {
+	spec, ref := *definition.Spec, types.NewReferenceFromResource(definition)
+
+	return types.Registration{
+		Factory: func() types.APIVersionKind { return &CustomSpec{definition: spec, definitionRef: ref} },
+		Names: types.ResourceNames{
+			Singular:   spec.Names.Singular,
+			Plural:     spec.Names.Plural,
+			ShortNames: spec.Names.ShortNames,
+		},
+		Defaulters: []types.DefaulterFunc{func(res *types.Resource[types.APIVersionKind]) {
+			spec, ok := res.Spec.(*CustomSpec)
+			if !ok {
+				return
+			}
+
+			if spec.Object == nil {
+				spec.Object = make(map[string]any)
+			}
+
+			spec.SpecSchema().ApplyDefaults(spec.Object)
+		}},
+		Validators: []types.ValidatorFunc{func(res types.Resource[types.APIVersionKind]) error {
+			spec, ok := res.Spec.(*CustomSpec)
+			if !ok {
+				return nil
+			}
+
+			return spec.SpecSchema().Validate(spec)
+		}},
+	}
+}
+
+// RegisterResourceDefinitions registers the kinds defined by the ResourceDefinitions of every
+// namespace of storage. The storage must implement types.NamespaceLister.
+// It returns types.ErrExists if a defined kind is already registered; the other kinds are
+// registered.
+func RegisterResourceDefinitions(mgr types.APIServer, storage types.Storage) error {
+	namespaceLister, ok := storage.(types.NamespaceLister)
+	if !ok {
+		return flaterrors.Join(types.ErrType, errors.New("storage cannot list its namespaces"))
+	}
+
+	namespaces, err := namespaceLister.Namespaces()
+	if err != nil {
+		return err
+	}
+
+	registrations := make([]types.Registration, 0)
+	for _, namespace := range namespaces {
+		list, err := types.ListTypedResourceFromStorage(storage, namespace, &ResourceDefinitionSpec{})
+		if err != nil {
+			return err
+		}
+
+		for _, res := range list {
+			registrations = append(registrations, NewCustomRegistration(res))
+		}
+	}
+
+	return mgr.Register(registrations)
+}
+
+// CustomSpec is the spec of the resources of a kind defined by a ResourceDefinition.
+type CustomSpec struct {
+	definition    ResourceDefinitionSpec
+	definitionRef types.Reference
+
+	// Object is the content of the spec.
+	Object map[string]any
+}
+
+// APIVersion returns the APIVersion of the defined kind.
+// It implements the types.DefinedResource interface.
+func (c CustomSpec) APIVersion() types.APIVersion {
+	return c.definition.DefinedAPIVersion()
+}
+
+// Kind returns the defined kind.
+// It implements the types.DefinedResource interface.
+func (c CustomSpec) Kind() types.Kind {
+	return c.definition.Names.Kind
+}
+
+// SpecSchema returns the schema of the spec, as declared by the ResourceDefinition.
+// It implements the types.SchemaProvider interface.
+func (c CustomSpec) SpecSchema() *types.Schema {
+	if c.definition.Schema != nil {
+		return c.definition.Schema
+	}
+
+	return &types.Schema{Type: "object"}
+}
+
+// References returns the ResourceDefinition of the spec.
+// It implements the types.ReferenceLister interface.
+func (c CustomSpec) References() []types.Reference {
+	return []types.Reference{c.definitionRef}
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (c CustomSpec) MarshalJSON() ([]byte, error) {
+	if c.Object == nil {
+		return []byte("{}"), nil
+	}
+
+	return json.Marshal(c.Object)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (c *CustomSpec) UnmarshalJSON(b []byte) error {
+	return json.Unmarshal(b, &c.Object)
+}
+
+// Render renders each top-level field of the spec, sorted by key, with the Resolver of its
+// definition. String values are passed as-is, and other values are encoded as JSON.
+// It implements the types.Renderer interface.
+func (c *CustomSpec) Render(storage types.Storage) (string, error) {
+	refs := c.definition.References()
+	if len(refs) == 0 {
+		return "", flaterrors.Join(types.ErrVal, fmt.Errorf(
+			"the ResourceDefinition of %s does not set a resolverRef", c.Kind()))
+	}
+
+	resolver, err := types.GetTypedResourceFromStorage(storage, refs[0].NamespacedName, &ResolverSpec{})
+	if err != nil {
+		return "", err
+	}
+
+	buf := ""
+	for _, key := range slices.Sorted(maps.Keys(c.Object)) {
+		value, ok := c.Object[key].(string)
+		if !ok {
+			b, err := json.Marshal(c.Object[key])
+			if err != nil {
+				return "", err
+			}
+
+			value = string(b)
+		}
+
+		s, err := resolver.Spec.Resolve(key, value)
+		if err != nil {
+			return "", err
+		}
+
+		buf = util.JoinLine(buf, s)
+	}
+
+	return buf, nil
+}
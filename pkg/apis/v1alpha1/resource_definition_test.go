@@ -0,0 +1,135 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1_test
+
+import (
+	"testing"
+
+	"github.com/alexandremahdhaoui/vib/internal/service"
+	"github.com/alexandremahdhaoui/vib/internal/types"
+	"github.com/alexandremahdhaoui/vib/pkg/apis/v1alpha1"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCustomRegistration(t *testing.T) {
+	apiServer := service.NewAPIServer()
+	v1alpha1.RegisterWithManager(apiServer)
+
+	definition := types.Resource[*v1alpha1.ResourceDefinitionSpec]{
+		APIVersion: v1alpha1.APIVersion,
+		Kind:       v1alpha1.ResourceDefinitionKind,
+		Metadata:   types.Metadata{Name: "sshhost", Namespace: types.VibSystemNamespace},
+		Spec: &v1alpha1.ResourceDefinitionSpec{
+			Group:   "example.com",
+			Version: "v1",
+			Names:   v1alpha1.ResourceDefinitionNames{Kind: "SSHHost"},
+			Schema: &types.Schema{
+				Type:     "object",
+				Required: []string{"hostname"},
+				Properties: map[string]*types.Schema{
+					"hostname": {Type: "string"},
+					"user":     {Type: "string", Default: "root"},
+				},
+				AdditionalProperties: false,
+			},
+		},
+	}
+
+	assert.NoError(t, apiServer.Register([]types.Registration{v1alpha1.NewCustomRegistration(definition)}))
+
+	newHost := func(t *testing.T, spec map[string]any) types.Resource[types.APIVersionKind] {
+		t.Helper()
+
+		res, err := apiServer.Get(types.NewAPIVersionKind("", "SSHHost"))
+		assert.NoError(t, err)
+		assert.Equal(t, "example.com/v1", res.APIVersion)
+
+		res.Metadata = types.Metadata{Name: "prod", Namespace: types.DefaultNamespace}
+		res.Spec.(*v1alpha1.CustomSpec).Object = spec
+
+		return res
+	}
+
+	t.Run("Admit", func(t *testing.T) {
+		res := newHost(t, map[string]any{"hostname": "prod.example.com"})
+
+		assert.NoError(t, apiServer.Admit(types.CreateOperation, &res))
+		assert.Equal(t, "root", res.Spec.(*v1alpha1.CustomSpec).Object["user"])
+		assert.Equal(t,
+			[]types.Reference{types.NewReferenceFromResource(definition)},
+			res.Spec.(*v1alpha1.CustomSpec).References(),
+		)
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		validate := v1alpha1.NewResourceDefinitionConflictValidator(apiServer)
+		newDefinition := func(name string) types.Resource[types.APIVersionKind] {
+			return types.Resource[types.APIVersionKind]{
+				APIVersion: definition.APIVersion,
+				Kind:       definition.Kind,
+				Metadata:   types.Metadata{Name: name, Namespace: types.VibSystemNamespace},
+				Spec:       definition.Spec,
+			}
+		}
+
+		// -- a ResourceDefinition can be updated, but its kind cannot be defined twice.
+		assert.NoError(t, validate(newDefinition("sshhost")))
+		assert.ErrorIs(t, validate(newDefinition("other")), types.ErrExists)
+
+		// -- the names of a kind cannot be used by another kind.
+		err := validate(types.Resource[types.APIVersionKind]{
+			APIVersion: definition.APIVersion,
+			Kind:       definition.Kind,
+			Metadata:   types.Metadata{Name: "widget", Namespace: types.VibSystemNamespace},
+			Spec: &v1alpha1.ResourceDefinitionSpec{
+				Group:   "aaa.example.com",
+				Version: "v1",
+				Names:   v1alpha1.ResourceDefinitionNames{Kind: "Widget", ShortNames: []string{"es"}},
+			},
+		})
+		assert.ErrorIs(t, err, types.ErrExists)
+		assert.ErrorContains(t, err, `name "es" is used by`)
+
+		err = apiServer.Register([]types.Registration{
+			v1alpha1.NewCustomRegistration(types.Resource[*v1alpha1.ResourceDefinitionSpec]{
+				APIVersion: definition.APIVersion,
+				Kind:       definition.Kind,
+				Metadata:   types.Metadata{Name: "other", Namespace: types.VibSystemNamespace},
+				Spec:       definition.Spec,
+			}),
+		})
+		assert.ErrorIs(t, err, types.ErrExists)
+
+		res, err := apiServer.Get(types.NewAPIVersionKind("", "SSHHost"))
+		assert.NoError(t, err)
+		assert.Equal(t,
+			[]types.Reference{types.NewReferenceFromResource(definition)},
+			res.Spec.(*v1alpha1.CustomSpec).References(),
+		)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		res := newHost(t, map[string]any{"user": 0, "port": 22})
+
+		err := apiServer.Admit(types.CreateOperation, &res)
+		assert.ErrorIs(t, err, types.ErrVal)
+		assert.ErrorContains(t, err, `missing required property "hostname"`)
+		assert.ErrorContains(t, err, `unknown property "port"`)
+		assert.ErrorContains(t, err, ".user: expected string, got number")
+	})
+}
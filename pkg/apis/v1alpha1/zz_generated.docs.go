@@ -0,0 +1,64 @@
+/*
+Copyright 2023 Alexandre Mahdhaoui
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by gendocs. DO NOT EDIT.
+
+package v1alpha1
+
+import "github.com/alexandremahdhaoui/vib/internal/types"
+
+func init() {
+	types.RegisterDocs("github.com/alexandremahdhaoui/vib/pkg/apis/v1alpha1", map[string]string{
+		"CustomSpec":                         "CustomSpec is the spec of the resources of a kind defined by a ResourceDefinition.",
+		"CustomSpec.Object":                  "Object is the content of the spec.",
+		"ExecResolverSpec":                   "ExecResolverSpec defines the configuration for an exec resolver.",
+		"ExecResolverSpec.Args":              "Args is a list of arguments to pass to the command.",
+		"ExecResolverSpec.Command":           "Command is the command to execute.",
+		"ExecResolverSpec.Stdin":             "Stdin is a string to be piped to the command's stdin.",
+		"ExpressionSetSpec":                  "ExpressionSetSpec defines the desired state of an ExpressionSet. It contains a set of expressions that can be rendered into a desired output and referenced in a profile.",
+		"ExpressionSetSpec.ArbitraryKeys":    "ArbitraryKeys is used for special resolvers, such as \"plain\", that do not require associated values. ArbitraryKeys are always rendered before KeyValues.",
+		"ExpressionSetSpec.KeyValues":        "KeyValues uses a list of maps to avoid reordered key-values.",
+		"ExpressionSetSpec.ResolverRef":      "ResolverRef is a reference to the Resolver that should be used to render this ExpressionSet.",
+		"FmtArgument":                        "FmtArgument is a string that represents a format argument.",
+		"FmtResolverSpec":                    "FmtResolverSpec defines the configuration for a fmt resolver.",
+		"FmtResolverSpec.FmtArguments":       "FmtArguments is a list of FmtArgument, that will be used to format the template.",
+		"FmtResolverSpec.Template":           "Template is the fmt template string.",
+		"GotemplateResolverSpec":             "GotemplateResolverSpec defines the configuration for a go-template resolver.",
+		"GotemplateResolverSpec.Template":    "Template is the go-template string.",
+		"LegacyExpressionSetSpec":            "LegacyExpressionSetSpec is the ExpressionSetSpec of the LegacyAPIVersion. It has the same fields as the ExpressionSetSpec.",
+		"PlainResolverSpec":                  "PlainResolverSpec defines the configuration for a plain resolver.",
+		"ProfileSpec":                        "ProfileSpec defines the desired state of a Profile. It contains a list of references to ExpressionSets that should be rendered to form the profile.",
+		"ProfileSpec.Refs":                   "Refs is a list of references to ExpressionSets. An ExpressionSet must not be referenced more than once.",
+		"Resolver":                           "Resolver is the interface that all resolvers must implement.",
+		"ResolverSpec":                       "ResolverSpec defines the desired state of a Resolver. It specifies the type of the resolver and its configuration.",
+		"ResolverSpec.Exec":                  "Exec is the configuration for an exec resolver.",
+		"ResolverSpec.Fmt":                   "Fmt is the configuration for a fmt resolver.",
+		"ResolverSpec.GoTemplate":            "GoTemplate is the configuration for a go-template resolver.",
+		"ResolverSpec.Plain":                 "Plain is the configuration for a plain resolver.",
+		"ResolverSpec.Type":                  "Type is the type of the resolver.",
+		"ResourceDefinitionNames":            "ResourceDefinitionNames are the names of a kind defined by a ResourceDefinition.",
+		"ResourceDefinitionNames.Kind":       "Kind is the kind of the defined resources, e.g. \"SSHHost\".",
+		"ResourceDefinitionNames.Plural":     "Plural is the lowercase plural name of the kind, e.g. \"sshhosts\". Defaults to the plural of the singular name.",
+		"ResourceDefinitionNames.ShortNames": "ShortNames are short lowercase aliases of the kind, e.g. \"ssh\".",
+		"ResourceDefinitionNames.Singular":   "Singular is the lowercase singular name of the kind. Defaults to the lowercase kind.",
+		"ResourceDefinitionSpec":             "ResourceDefinitionSpec defines the desired state of a ResourceDefinition. It declares a new kind of resources, whose spec is described by a JSON Schema.",
+		"ResourceDefinitionSpec.Group":       "Group is the API group of the defined kind, e.g. \"example.com\".",
+		"ResourceDefinitionSpec.Names":       "Names are the names of the defined kind.",
+		"ResourceDefinitionSpec.ResolverRef": "ResolverRef is a reference to the Resolver used to render the defined resources. Each top-level field of their spec is resolved as a key-value, sorted by key.",
+		"ResourceDefinitionSpec.Schema":      "Schema is the JSON Schema of the spec of the defined resources. Specs are only required to be objects if it is not set.",
+		"ResourceDefinitionSpec.Version":     "Version is the version of the defined kind, e.g. \"v1\".",
+	})
+}